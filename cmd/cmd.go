@@ -1,26 +1,52 @@
 package cmd
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"syscall"
 
 	"github.com/ahmed-e-abdulaziz/glsync/code"
 	"github.com/ahmed-e-abdulaziz/glsync/config"
 	"github.com/ahmed-e-abdulaziz/glsync/git"
-	"github.com/ahmed-e-abdulaziz/glsync/handler"
 )
 
 const (
-	lcCookieArg    = "lc-cookie"
-	repoUrlArg     = "repo-url"
-	bearerTokenArg = "bearer-token"
-	siteArg           = "site"
-	lcCsrfTokenArg    = "lc-csrf-token"
-	lcCfClearanceArg  = "lc-cf-clearance"
+	lcCookieArg        = "lc-cookie"
+	repoUrlArg         = "repo-url"
+	repoPathArg        = "repo-path"
+	bearerTokenArg     = "bearer-token"
+	cookieOnlyArg      = "cookie-only"
+	siteArg            = "site"
+	lcCsrfTokenArg     = "lc-csrf-token"
+	lcCfClearanceArg   = "lc-cf-clearance"
+	allSubmissionsArg  = "all-submissions"
+	allLanguagesArg    = "all-languages"
+	stateFileArg       = "state-file"
+	concurrencyArg     = "concurrency"
+	rateLimitQuotaArg  = "rate-limit-quota"
+	rateLimitWindowArg = "rate-limit-window"
+	maxRetriesArg      = "max-retries"
+	retryBaseDelayArg  = "retry-base-delay"
+	retryMaxDelayArg   = "retry-max-delay"
+	requestTimeoutArg  = "request-timeout"
+	queriesDirArg      = "queries-dir"
+	cookiesFileArg     = "cookies-file"
+	gitClientArg       = "git-client"
+	configArg          = "config"
+	// Suffix of the flags reading a secret from a file, e.g. -lc-cookie-file
+	secretFileSuffix = "-file"
+	// Prefix of the environment variables setting the flags, e.g. GLSYNC_LC_COOKIE sets -lc-cookie
+	envVarPrefix = "GLSYNC_"
 )
 
 var graphqlURLBySite = map[string]string{
@@ -31,58 +57,267 @@ var graphqlURLBySite = map[string]string{
 // Execute is the CLI entry point. urlOverride is used by tests to point at a
 // mock server; pass an empty string in production and the URL will be derived
 // from the --site flag.
+//
+// The first argument picks the command to run, see commands, and the sync command is run
+// if it's a flag or missing, as glsync only synced before it had commands.
+//
+// SIGINT and SIGTERM cancel the command, requests and git commands in flight are stopped and nothing is pushed.
+//
+// Execute returns if the command succeeds, otherwise it prints why it failed and exits with the code in exit.go.
 func Execute(urlOverride string) {
 	log.SetFlags(0)
 	log.SetOutput(os.Stdout)
+	var cfg config.Config
+	defer func() {
+		// Only bugs panic. Panicking again would have Go print r as it is, so what it panicked with
+		// and its stack trace are printed with the secrets redacted instead, in case they have them.
+		// Panics of other goroutines aren't recovered here and are printed by Go.
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "panic: %v\n\n%v", cfg.Redact(fmt.Sprint(r)), cfg.Redact(string(debug.Stack())))
+			os.Exit(exitFailure)
+		}
+	}()
+	exitOnError(run(&cfg, urlOverride), cfg)
+}
 
-	initUsageFunc()
-	cfg := initConfig()
-
-	graphqlURL := urlOverride
-	if graphqlURL == "" {
-		url, ok := graphqlURLBySite[cfg.LcSite]
-		if !ok {
-			log.Panicf("Unknown site %q, valid values are: com, cn", cfg.LcSite)
+// Runs the command picked by os.Args, cfg is set to its options once they are loaded
+func run(cfg *config.Config, urlOverride string) error {
+	cmd, args, err := findCommand(os.Args[1:])
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	*cfg, err = loadConfig(cmd.flagSet(), cmd.flags, args)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+	log.SetOutput(cfg.RedactingWriter(os.Stdout))
+	if cmd.validate {
+		if err := validateConfig(*cfg, cmd.needsRepo); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidConfig, err)
 		}
-		graphqlURL = url
+		log.Println("Input parsed successfully.")
 	}
 
-	lc := code.NewLeetCode(cfg, graphqlURL)
-	gh := git.NewGitCli(cfg)
-	handler := handler.NewHandler(lc, gh)
-	handler.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop() // Restore the default behavior so a second signal kills glsync right away
+	}()
+	return cmd.run(ctx, *cfg, urlOverride)
 }
 
-func initUsageFunc() {
-	oldUsage := flag.Usage
-	flag.Usage = func() {
-		log.Print("CLI tool to sync all your LeetCode submissions to Github (And possibly any other git client)\n\n")
-		oldUsage()
+// Returns the URL of the GraphQL API of cfg.LcSite, or urlOverride if it's set
+func graphqlURLOf(cfg config.Config, urlOverride string) (string, error) {
+	if urlOverride != "" {
+		return urlOverride, nil
+	}
+	url, ok := graphqlURLBySite[cfg.LcSite]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown site %q, valid values are: com, cn", errInvalidConfig, cfg.LcSite)
 	}
+	return url, nil
 }
 
-func initConfig() config.Config {
-	cfg := config.Config{}
-	flag.StringVar(&cfg.LcCookie, lcCookieArg, "", "The cookie of your LeetCode session, refer to the README.md for more info")
-	flag.StringVar(&cfg.RepoUrl, repoUrlArg, "", "The git repo's url to push LC submissions to")
-	flag.StringVar(&cfg.LcSite, siteArg, "com", "LeetCode site to sync from: \"com\" for leetcode.com (default) or \"cn\" for leetcode.cn")
-	flag.StringVar(&cfg.LcCsrfToken, lcCsrfTokenArg, "", "CSRF token for leetcode.cn (value of the csrftoken cookie in your browser); required when -site=cn")
-	flag.StringVar(&cfg.LcCfClearance, lcCfClearanceArg, "", "Cloudflare clearance token for leetcode.cn (value of the cf_clearance cookie in your browser); required when -site=cn")
-	flag.Parse()
+// Checks the LeetCode session and returns the client to fetch the submissions with,
+// commands call it before cloning so an expired cookie fails before any git work is done
+func signIn(ctx context.Context, cfg config.Config, urlOverride string) (code.CodeClient, error) {
+	url, err := graphqlURLOf(cfg, urlOverride)
+	if err != nil {
+		return nil, err
+	}
+	lc := code.NewLeetCode(cfg, url)
+	session, err := lc.CheckSession(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't verify the LeetCode session: %w", err)
+	}
+	log.Printf("Signed in to %v as %v\n", session.Site, session.Username)
+	return lc, nil
+}
+
+// Returns an error if cfg misses an option the commands need, the repo's url is only checked if needsRepo is set
+func validateConfig(cfg config.Config, needsRepo bool) error {
 	if cfg.LcCookie == "" || !isValidCookie(cfg.LcCookie) {
-		log.Panicf("Invalid leet code session cookie provided, use -%v option to provide your leetcode cookie", lcCookieArg)
+		return fmt.Errorf("invalid leet code session cookie provided, use -%v option to provide your leetcode cookie or -%v to read it from your browser's cookies", lcCookieArg, cookiesFileArg)
 	}
-	if cfg.RepoUrl == "" {
-		log.Panicf("No git repo url was provided, use -%v option to provide your git repo url ", repoUrlArg)
+	if needsRepo && cfg.RepoUrl == "" && cfg.RepoPath == "" {
+		return fmt.Errorf("no git repo url was provided, use -%v option to provide your git repo url or -%v to commit into a checkout of it", repoUrlArg, repoPathArg)
 	}
 	if cfg.LcSite == "cn" && cfg.LcCsrfToken == "" {
-		log.Panicf("leetcode.cn requires a CSRF token, use -%v option to provide it", lcCsrfTokenArg)
+		return fmt.Errorf("leetcode.cn requires a CSRF token, use -%v or -%v option to provide it", lcCsrfTokenArg, cookiesFileArg)
 	}
 	if cfg.LcSite == "cn" && cfg.LcCfClearance == "" {
-		log.Panicf("leetcode.cn requires a Cloudflare clearance token, use -%v option to provide it (get the cf_clearance cookie value from your browser after visiting leetcode.cn)", lcCfClearanceArg)
+		return fmt.Errorf("leetcode.cn requires a Cloudflare clearance token, use -%v option to provide it (get the cf_clearance cookie value from your browser after visiting leetcode.cn)", lcCfClearanceArg)
+	}
+	if cfg.CookieOnly && cfg.BearerToken != "" {
+		return fmt.Errorf("-%v and -%v can't be used together, -%v sends no Authorization header", cookieOnlyArg, bearerTokenArg, cookieOnlyArg)
+	}
+	if cfg.Concurrency < 1 {
+		return fmt.Errorf("invalid concurrency %v, use -%v option with a value of 1 or more", cfg.Concurrency, concurrencyArg)
+	}
+	if _, ok := graphqlURLBySite[cfg.LcSite]; !ok {
+		return fmt.Errorf("unknown site %q, use -%v option with one of: com, cn", cfg.LcSite, siteArg)
+	}
+	if needsRepo && !slices.Contains(git.Clients, cfg.GitClient) {
+		return fmt.Errorf("unknown git client %q, use -%v option with one of: %v", cfg.GitClient, gitClientArg, strings.Join(git.Clients, ", "))
+	}
+	return nil
+}
+
+// flagGroup registers a group of related flags setting cfg in fs, a command only has the groups of the options it uses.
+// It returns the secrets among them, which can also be read from the files passed with their -file flags.
+type flagGroup func(fs *flag.FlagSet, cfg *config.Config) []secretSource
+
+// Every group of flags, which the commands using all the options have
+var allFlags = []flagGroup{leetcodeFlags, fetchFlags, stateFlags, repoFlags}
+
+// The flags of the LeetCode site and the credentials to sign in to it with
+func leetcodeFlags(fs *flag.FlagSet, cfg *config.Config) []secretSource {
+	fs.StringVar(&cfg.LcCookie, lcCookieArg, "", "The cookie of your LeetCode session, refer to the README.md for more info")
+	fs.StringVar(&cfg.BearerToken, bearerTokenArg, "", "Token sent to LeetCode as an Authorization: Bearer header along with the cookie, defaults to the cookie itself")
+	fs.BoolVar(&cfg.CookieOnly, cookieOnlyArg, false, "Only send the cookies to LeetCode, without the Authorization: Bearer header")
+	fs.StringVar(&cfg.LcSite, siteArg, "com", "LeetCode site to sync from: \"com\" for leetcode.com (default) or \"cn\" for leetcode.cn")
+	fs.StringVar(&cfg.LcCsrfToken, lcCsrfTokenArg, "", "CSRF token for leetcode.cn (value of the csrftoken cookie in your browser); required when -site=cn")
+	fs.StringVar(&cfg.LcCfClearance, lcCfClearanceArg, "", "Cloudflare clearance token for leetcode.cn (value of the cf_clearance cookie in your browser); required when -site=cn")
+	fs.StringVar(&cfg.CookiesFile, cookiesFileArg, "", "Cookies exported from your browser, as a Netscape cookies.txt or JSON file, to read the LeetCode cookies from instead of passing them one by one")
+	return []secretSource{
+		{&cfg.LcCookie, lcCookieArg, ""},
+		{&cfg.LcCsrfToken, lcCsrfTokenArg, ""},
+		{&cfg.LcCfClearance, lcCfClearanceArg, ""},
+		{&cfg.BearerToken, bearerTokenArg, ""},
+	}
+}
+
+// The flags of which submissions are fetched from LeetCode and how
+func fetchFlags(fs *flag.FlagSet, cfg *config.Config) []secretSource {
+	fs.BoolVar(&cfg.AllSubmissions, allSubmissionsArg, false, "Sync every accepted submission of each question, oldest first, instead of only the latest one")
+	fs.BoolVar(&cfg.AllLanguages, allLanguagesArg, false, "Sync the latest accepted submission in every language of each question, written side by side in the question's folder")
+	fs.IntVar(&cfg.Concurrency, concurrencyArg, 1, "Number of questions to fetch submissions for in parallel, requests are still rate limited together")
+	fs.IntVar(&cfg.RateLimitQuota, rateLimitQuotaArg, 0, "LeetCode requests allowed per rate limit window, defaults to 60 for leetcode.cn and 300 for leetcode.com")
+	fs.DurationVar(&cfg.RateLimitWindow, rateLimitWindowArg, 0, "Window of the rate limit quota (e.g. 10m), defaults to 10m for leetcode.cn and 1m for leetcode.com")
+	fs.IntVar(&cfg.MaxRetries, maxRetriesArg, 0, "Retries of a failed LeetCode request, defaults to 25")
+	fs.DurationVar(&cfg.RetryBaseDelay, retryBaseDelayArg, 0, "Delay before the first retry of a failed LeetCode request, doubled on each following retry, defaults to 1s")
+	fs.DurationVar(&cfg.RetryMaxDelay, retryMaxDelayArg, 0, "Upper bound of the delay between retries of a failed LeetCode request, defaults to 30s")
+	fs.DurationVar(&cfg.RequestTimeout, requestTimeoutArg, 0, "Timeout of a single LeetCode request, a timed out request is retried, defaults to 30s")
+	fs.StringVar(&cfg.QueriesDir, queriesDirArg, "", "Folder of GraphQL query files replacing the built-in ones with the same name, to work around LeetCode API changes before a new release")
+	return nil
+}
+
+// The flag of the file remembering what was synced, for the commands skipping what was synced before
+func stateFlags(fs *flag.FlagSet, cfg *config.Config) []secretSource {
+	fs.StringVar(&cfg.StateFile, stateFileArg, "", "Path of a file to keep the sync state in, later runs only sync questions submitted since the last run (e.g. glsync-state.json)")
+	return nil
+}
+
+// The flags of the repo and the git client committing to it
+func repoFlags(fs *flag.FlagSet, cfg *config.Config) []secretSource {
+	fs.StringVar(&cfg.RepoUrl, repoUrlArg, "", "The git repo's url to push LC submissions to")
+	return checkoutFlags(fs, cfg)
+}
+
+// The flags of a checkout of the repo and the git client reading it, for the commands that don't clone the repo
+func checkoutFlags(fs *flag.FlagSet, cfg *config.Config) []secretSource {
+	fs.StringVar(&cfg.RepoPath, repoPathArg, "", "Existing checkout of the repo to commit into and push from instead of cloning -"+repoUrlArg+" in a temp folder, it's left in place")
+	fs.StringVar(&cfg.GitClient, gitClientArg, git.CliClient, "How to commit: \"cli\" runs git add and git commit for each submission, \"fast-import\" writes all the commits at once with git fast-import, which is much faster for large accounts, \"go-git\" commits without the git binary")
+	return nil
+}
+
+// Reads the options from, in increasing order of precedence: the flags' defaults, the config file,
+// the GLSYNC_ environment variables and the flags passed in args, which are parsed with fs.
+// Only the flags of groups are registered in fs, though the config file can set any option.
+func loadConfig(fs *flag.FlagSet, groups []flagGroup, args []string) (config.Config, error) {
+	cfg := config.Config{}
+	var configFile string
+	fs.StringVar(&configFile, configArg, "", "YAML file to read the options from, its keys are the names of the flags (e.g. repo-url: ...), defaults to ~/.config/glsync/config.yaml")
+	var secrets []secretSource
+	for _, group := range groups {
+		secrets = append(secrets, group(fs, &cfg)...)
+	}
+	for idx := range secrets {
+		fs.StringVar(&secrets[idx].file, secrets[idx].arg+secretFileSuffix, "",
+			fmt.Sprintf("File to read -%v from instead, - reads it from stdin. It can also be set with the %v environment variable", secrets[idx].arg, envVarOf(secrets[idx].arg)))
+	}
+	fs.Parse(args) // Exits on invalid flags as the flag set is flag.ExitOnError
+
+	// The flags passed are set again once the config file and environment variables are read, as they take precedence
+	passed := map[string]string{}
+	fs.Visit(func(f *flag.Flag) { passed[f.Name] = f.Value.String() })
+	configFileRequired := true
+	if configFile == "" {
+		configFile = os.Getenv(envVarOf(configArg))
+	}
+	if configFile == "" {
+		configFile, configFileRequired = config.DefaultFilePath(), false
+	}
+	if configFile != "" {
+		if err := cfg.LoadFile(configFile, configFileRequired); err != nil {
+			return cfg, fmt.Errorf("invalid -%v: %w", configArg, err)
+		}
+	}
+	var envErrs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := passed[f.Name]; ok {
+			return
+		}
+		if value := os.Getenv(envVarOf(f.Name)); value != "" {
+			if err := f.Value.Set(value); err != nil {
+				envErrs = append(envErrs, fmt.Errorf("invalid %v environment variable: %w", envVarOf(f.Name), err))
+			}
+		}
+	})
+	if len(envErrs) > 0 {
+		return cfg, errors.Join(envErrs...)
+	}
+	for name, value := range passed {
+		fs.Set(name, value)
+	}
+
+	if err := readSecrets(secrets, passed); err != nil {
+		return cfg, err
+	}
+	if cfg.CookiesFile != "" {
+		if err := cfg.LoadCookiesFile(cfg.CookiesFile); err != nil {
+			return cfg, fmt.Errorf("%w, export the cookies of %v again or use -%v instead", err, "leetcode."+cfg.LcSite, lcCookieArg)
+		}
+	}
+	return cfg, nil
+}
+
+// Returns the environment variable setting the flag named name, e.g. GLSYNC_LC_COOKIE for lc-cookie
+func envVarOf(name string) string {
+	return envVarPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// secretSource is where a secret can be read from when its flag isn't passed
+type secretSource struct {
+	value *string
+	arg   string // Name of the secret's flag
+	file  string // Set by the secret's -file flag
+}
+
+// Reads the secrets whose flags weren't passed from their -file flags, which take precedence over
+// the config file and environment variables
+func readSecrets(secrets []secretSource, passed map[string]string) error {
+	fromStdin := ""
+	for _, secret := range secrets {
+		if _, ok := passed[secret.arg]; ok {
+			continue
+		}
+		if secret.file == "-" {
+			if fromStdin != "" {
+				return fmt.Errorf("only one secret can be read from stdin, both -%v and -%v were set to -", fromStdin+secretFileSuffix, secret.arg+secretFileSuffix)
+			}
+			fromStdin = secret.arg
+		}
+		if secret.file != "" {
+			value, err := config.ReadSecretFile(secret.file, os.Stdin)
+			if err != nil {
+				return fmt.Errorf("invalid -%v: %w", secret.arg+secretFileSuffix, err)
+			}
+			*secret.value = value
+		}
 	}
-	log.Println("Input parsed successfully.")
-	return cfg
+	return nil
 }
 
 func isValidCookie(cookie string) bool {
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	homeConfigFile = ".config/glsync/config.yaml" // Relative to the home folder, which is the test's folder
+	cookiesFile    = "# Netscape HTTP Cookie File\n" +
+		"#HttpOnly_.leetcode.com\tTRUE\t/\tTRUE\t0\tLEETCODE_SESSION\tCOOKIES_FILE_SESSION\n" +
+		".leetcode.com\tTRUE\t/\tTRUE\t0\tcsrftoken\tCOOKIES_FILE_CSRF\n"
+)
+
+func TestLoadConfig(t *testing.T) {
+	for name, testCase := range map[string]struct {
+		files    map[string]string // Written in the test's folder, "{dir}" is replaced by its path in env, args and expected.CookiesFile
+		env      map[string]string
+		args     []string
+		groups   []flagGroup // allFlags if nil
+		expected config.Config
+	}{
+		"defaults": {
+			expected: config.Config{LcSite: "com", Concurrency: 1, GitClient: "cli"},
+		},
+		"default config file over the defaults": {
+			files:    map[string]string{homeConfigFile: "site: cn\nconcurrency: 4\n"},
+			expected: config.Config{LcSite: "cn", Concurrency: 4, GitClient: "cli"},
+		},
+		"environment variables over the config file": {
+			files:    map[string]string{homeConfigFile: "site: cn\nconcurrency: 4\n"},
+			env:      map[string]string{"GLSYNC_CONCURRENCY": "6"},
+			expected: config.Config{LcSite: "cn", Concurrency: 6, GitClient: "cli"},
+		},
+		"flags over environment variables": {
+			files:    map[string]string{homeConfigFile: "site: cn\nconcurrency: 4\n"},
+			env:      map[string]string{"GLSYNC_CONCURRENCY": "6"},
+			args:     []string{"-concurrency=8"},
+			expected: config.Config{LcSite: "cn", Concurrency: 8, GitClient: "cli"},
+		},
+		"-config instead of the default config file": {
+			files:    map[string]string{homeConfigFile: "site: cn\n", "other.yaml": "concurrency: 5\n"},
+			args:     []string{"-config={dir}/other.yaml"},
+			expected: config.Config{LcSite: "com", Concurrency: 5, GitClient: "cli"},
+		},
+		"GLSYNC_CONFIG instead of the default config file": {
+			files:    map[string]string{homeConfigFile: "site: cn\n", "other.yaml": "concurrency: 5\n"},
+			env:      map[string]string{"GLSYNC_CONFIG": "{dir}/other.yaml"},
+			expected: config.Config{LcSite: "com", Concurrency: 5, GitClient: "cli"},
+		},
+		"-config over GLSYNC_CONFIG": {
+			files:    map[string]string{"other.yaml": "concurrency: 5\n", "flag.yaml": "concurrency: 7\n"},
+			env:      map[string]string{"GLSYNC_CONFIG": "{dir}/other.yaml"},
+			args:     []string{"-config={dir}/flag.yaml"},
+			expected: config.Config{LcSite: "com", Concurrency: 7, GitClient: "cli"},
+		},
+		"secret files over environment variables and the config file": {
+			files:    map[string]string{homeConfigFile: "lc-cookie: CONFIG_FILE_SESSION\n", "session": "FILE_SESSION\n"},
+			env:      map[string]string{"GLSYNC_LC_COOKIE": "ENV_SESSION"},
+			args:     []string{"-lc-cookie-file={dir}/session"},
+			expected: config.Config{LcCookie: "FILE_SESSION", LcSite: "com", Concurrency: 1, GitClient: "cli"},
+		},
+		"secret files set by environment variables": {
+			files:    map[string]string{"session": "FILE_SESSION\n"},
+			env:      map[string]string{"GLSYNC_LC_COOKIE_FILE": "{dir}/session"},
+			expected: config.Config{LcCookie: "FILE_SESSION", LcSite: "com", Concurrency: 1, GitClient: "cli"},
+		},
+		"secret flags over secret files": {
+			files:    map[string]string{"session": "FILE_SESSION\n"},
+			args:     []string{"-lc-cookie-file={dir}/session", "-lc-cookie=FLAG_SESSION"},
+			expected: config.Config{LcCookie: "FLAG_SESSION", LcSite: "com", Concurrency: 1, GitClient: "cli"},
+		},
+		"cookies file for the secrets that aren't set": {
+			files: map[string]string{"cookies.txt": cookiesFile},
+			args:  []string{"-cookies-file={dir}/cookies.txt", "-lc-csrf-token=FLAG_CSRF"},
+			expected: config.Config{LcCookie: "COOKIES_FILE_SESSION", LcCsrfToken: "FLAG_CSRF", LcSite: "com", Concurrency: 1,
+				GitClient: "cli", CookiesFile: "{dir}/cookies.txt"},
+		},
+		"cookies file after the secret files": {
+			files: map[string]string{"cookies.txt": cookiesFile, "session": "FILE_SESSION\n"},
+			env:   map[string]string{"GLSYNC_COOKIES_FILE": "{dir}/cookies.txt"},
+			args:  []string{"-lc-cookie-file={dir}/session"},
+			expected: config.Config{LcCookie: "FILE_SESSION", LcCsrfToken: "COOKIES_FILE_CSRF", LcSite: "com", Concurrency: 1,
+				GitClient: "cli", CookiesFile: "{dir}/cookies.txt"},
+		},
+		"only the flags of the command's groups": {
+			files:    map[string]string{homeConfigFile: "repo-url: CONFIG_FILE_REPO\n"},
+			env:      map[string]string{"GLSYNC_REPO_URL": "ENV_REPO", "GLSYNC_STATE_FILE": "state.json"},
+			groups:   []flagGroup{leetcodeFlags},
+			expected: config.Config{RepoUrl: "CONFIG_FILE_REPO", LcSite: "com"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			dir := t.TempDir()
+			t.Setenv("HOME", dir)
+			for path, content := range testCase.files {
+				require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o700))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0o600))
+			}
+			for key, value := range testCase.env {
+				t.Setenv(key, strings.ReplaceAll(value, "{dir}", dir))
+			}
+			args := make([]string, len(testCase.args))
+			for idx, arg := range testCase.args {
+				args[idx] = strings.ReplaceAll(arg, "{dir}", dir)
+			}
+			groups := testCase.groups
+			if groups == nil {
+				groups = allFlags
+			}
+			expected := testCase.expected
+			expected.CookiesFile = strings.ReplaceAll(expected.CookiesFile, "{dir}", dir)
+
+			// When
+			cfg, err := loadConfig(flag.NewFlagSet("glsync", flag.ContinueOnError), groups, args)
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, expected, cfg)
+		})
+	}
+}
+
+func TestLoadConfigShouldFailOnInvalidOptions(t *testing.T) {
+	for name, testCase := range map[string]struct {
+		env  map[string]string
+		args []string
+	}{
+		"missing -config file":           {args: []string{"-config=/missing/config.yaml"}},
+		"missing GLSYNC_CONFIG file":     {env: map[string]string{"GLSYNC_CONFIG": "/missing/config.yaml"}},
+		"invalid environment variable":   {env: map[string]string{"GLSYNC_CONCURRENCY": "many"}},
+		"missing secret file":            {args: []string{"-lc-cookie-file=/missing/session"}},
+		"missing secret file set by env": {env: map[string]string{"GLSYNC_LC_CSRF_TOKEN_FILE": "/missing/csrf"}},
+		"missing cookies file":           {args: []string{"-cookies-file=/missing/cookies.txt"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			t.Setenv("HOME", t.TempDir())
+			for key, value := range testCase.env {
+				t.Setenv(key, value)
+			}
+
+			// When
+			_, err := loadConfig(flag.NewFlagSet("glsync", flag.ContinueOnError), allFlags, testCase.args)
+
+			// Then
+			assert.Error(t, err)
+		})
+	}
+}
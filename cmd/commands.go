@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/ahmed-e-abdulaziz/glsync/git"
+	"github.com/ahmed-e-abdulaziz/glsync/handler"
+)
+
+const defaultCommand = "sync"
+
+// Returned by the verify command when the repo doesn't match LeetCode
+var errRepoOutOfSync = errors.New("the repo doesn't match your LeetCode submissions")
+
+type command struct {
+	name        string
+	description string // Shown in the help of glsync and of the command itself
+	validate    bool   // Validate the options before running, see validateConfig
+	needsRepo   bool   // Validate that -repo-url is set as well
+	flags       []flagGroup
+	run         func(ctx context.Context, cfg config.Config, urlOverride string) error
+}
+
+// The commands of glsync in the order they are listed in its help
+var commands = []command{
+	{"sync", "Commit your LeetCode submissions to the repo and push them, the default command", true, true,
+		allFlags, runSync},
+	{"status", "List the submissions the next sync would commit, without cloning the repo, skipping the ones committed to -repo-path if it's set", true, false,
+		[]flagGroup{leetcodeFlags, fetchFlags, stateFlags, checkoutFlags}, runStatus},
+	{"verify", "Check that the repo has the code of every submission, exits with 1 if it doesn't", true, true,
+		[]flagGroup{leetcodeFlags, fetchFlags, repoFlags}, runVerify},
+	{"stats", "Summarize your accepted submissions by question and language", true, false,
+		[]flagGroup{leetcodeFlags, fetchFlags}, runStats},
+	{"doctor", "Check the options, git, the repo and the LeetCode session, exits with 1 if a check fails", false, false,
+		[]flagGroup{leetcodeFlags, fetchFlags, repoFlags}, runDoctor},
+	{"config show", "Print the options read from the flags, environment variables and config file, with the secrets redacted", false, false,
+		allFlags, runConfigShow},
+}
+
+// Returns the command args starts with and the args that follow it,
+// the default command is returned along with all of args if they don't start with a command
+func findCommand(args []string) (command, []string, error) {
+	name, rest := defaultCommand, args
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, rest = args[0], args[1:]
+		if name == "config" && len(rest) > 0 {
+			name, rest = name+" "+rest[0], rest[1:]
+		}
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, rest, nil
+		}
+	}
+	return command{}, nil, fmt.Errorf("unknown command %q, run glsync -h to list the commands", name)
+}
+
+// Returns the flag set to parse the command's flags with, its help shows the command's description
+// and the list of commands when no command was given
+func (c command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("glsync "+c.name, flag.ExitOnError)
+	fs.Usage = func() {
+		if c.name == defaultCommand {
+			log.Print("CLI tool to sync all your LeetCode submissions to Github (And possibly any other git client)\n\n")
+			log.Print("Usage:\n  glsync [command] [flags]\n\nCommands:\n")
+			for _, cmd := range commands {
+				log.Printf("  %-12v %v\n", cmd.name, cmd.description)
+			}
+			log.Printf("\nRun glsync <command> -h for the help of a command. The flags of %v are:\n", defaultCommand)
+		} else {
+			log.Printf("Usage:\n  glsync %v [flags]\n\n%v\n\nFlags:\n", c.name, c.description)
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runSync(ctx context.Context, cfg config.Config, urlOverride string) error {
+	lc, err := signIn(ctx, cfg, urlOverride)
+	if err != nil {
+		return err
+	}
+	gh, err := git.NewGitClient(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", handler.ErrGitFailed, err)
+	}
+	defer gh.Close() // Push and Verify remove the clone already, this is for the failures before them
+	handler := handler.NewHandler(cfg, lc, gh)
+	return handler.Execute(ctx)
+}
+
+func runStatus(ctx context.Context, cfg config.Config, urlOverride string) error {
+	lc, err := signIn(ctx, cfg, urlOverride)
+	if err != nil {
+		return err
+	}
+	// The trailers of the commits are only read from a checkout, as status doesn't clone the repo
+	var gh git.GitClient
+	if cfg.RepoPath != "" {
+		if gh, err = git.NewGitClient(ctx, cfg); err != nil {
+			return fmt.Errorf("%w: %w", handler.ErrGitFailed, err)
+		}
+		defer gh.Close()
+	}
+	// On a partial fetch the submissions of the other questions are listed before failing
+	pending, err := handler.NewHandler(cfg, lc, gh).Status(ctx)
+	if err != nil && !errors.Is(err, handler.ErrPartialSync) {
+		return err
+	}
+	if len(pending) == 0 && err == nil {
+		log.Println("Nothing to sync, every submission was synced before")
+		return nil
+	}
+	log.Printf("The next sync would commit %v submissions:\n", len(pending))
+	for _, s := range pending {
+		log.Printf("\t%v %v (%v), submitted at %v\n", s.Id, s.Title, s.Lang, s.LastSubmittedAt.Format(time.RFC3339))
+	}
+	return err
+}
+
+func runVerify(ctx context.Context, cfg config.Config, urlOverride string) error {
+	lc, err := signIn(ctx, cfg, urlOverride)
+	if err != nil {
+		return err
+	}
+	gh, err := git.NewGitClient(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", handler.ErrGitFailed, err)
+	}
+	defer gh.Close() // Push and Verify remove the clone already, this is for the failures before them
+	mismatches, err := handler.NewHandler(cfg, lc, gh).Verify(ctx)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) == 0 {
+		log.Println("The repo has the code of every submission")
+		return nil
+	}
+	for _, m := range mismatches {
+		reason := "has different code"
+		if m.Missing {
+			reason = "is missing"
+		}
+		log.Printf("\t%v %v, submission of question %v %v\n", m.Path, reason, m.Submission.Id, m.Submission.Title)
+	}
+	return fmt.Errorf("%w, %v files don't have the code of their submission", errRepoOutOfSync, len(mismatches))
+}
+
+func runStats(ctx context.Context, cfg config.Config, urlOverride string) error {
+	lc, err := signIn(ctx, cfg, urlOverride)
+	if err != nil {
+		return err
+	}
+	// On a partial fetch the stats of the other questions are printed before failing
+	stats, err := handler.NewHandler(cfg, lc, nil).Stats(ctx)
+	if err != nil && !errors.Is(err, handler.ErrPartialSync) {
+		return err
+	}
+	log.Printf("Questions: %v\n", stats.Questions)
+	log.Printf("Submissions: %v\n", stats.Submissions)
+	if stats.Submissions == 0 {
+		return err
+	}
+	log.Printf("First submission: %v\n", stats.FirstSubmittedAt.Format(time.DateOnly))
+	log.Printf("Last submission: %v\n", stats.LastSubmittedAt.Format(time.DateOnly))
+	langs := make([]string, 0, len(stats.Languages))
+	for lang := range stats.Languages {
+		langs = append(langs, lang)
+	}
+	// Most used languages first
+	sort.Slice(langs, func(i, j int) bool {
+		if stats.Languages[langs[i]] != stats.Languages[langs[j]] {
+			return stats.Languages[langs[i]] > stats.Languages[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	log.Println("Languages:")
+	for _, lang := range langs {
+		log.Printf("\t%v: %v\n", lang, stats.Languages[lang])
+	}
+	return err
+}
+
+// Prints the options glsync would run with, as a config file with the secrets redacted
+func runConfigShow(_ context.Context, cfg config.Config, _ string) error {
+	content, err := cfg.Redacted().Yaml()
+	if err != nil {
+		return fmt.Errorf("couldn't print the config: %w", err)
+	}
+	_, err = fmt.Fprint(os.Stdout, string(content))
+	return err
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSyncShouldRemoveTheCloneWhenTheSyncFails(t *testing.T) {
+	// Given
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir) // The clone's temp folder is created in it
+	repoUrl := filepath.Join(t.TempDir(), "repo.git")
+	out, err := exec.Command("git", "init", "--bare", repoUrl).CombinedOutput()
+	require.NoError(t, err, string(out))
+	// Signs in then fails fetching the questions
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBody, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(reqBody), "userStatus") {
+			w.Write([]byte(`{"data": {"userStatus": {"isSignedIn": true, "username": "jane"}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	cfg := config.Config{LcCookie: "cookie", RepoUrl: repoUrl, Concurrency: 1, MaxRetries: 1, GitClient: "cli"}
+
+	// When
+	err = runSync(context.Background(), cfg, server.URL)
+
+	// Then
+	assert.Error(t, err)
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/code"
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/ahmed-e-abdulaziz/glsync/git"
+)
+
+// Returned by the doctor command when any of its checks fails
+var errChecksFailed = errors.New("some checks failed")
+
+// Retries of the LeetCode requests made by the doctor command when -max-retries isn't set,
+// so it reports a network failure in seconds instead of retrying for minutes like a sync
+const doctorMaxRetries = 2
+
+// checkResult is the outcome of one of the doctor command's checks
+type checkResult struct {
+	name    string
+	detail  string // What was found when the check passed
+	err     error  // Why the check failed
+	fix     string // How to fix the failure
+	skipped bool   // Not checked as a check it depends on failed
+}
+
+// Runs every check and prints them as a checklist, returns errChecksFailed if any of them failed
+func runDoctor(ctx context.Context, cfg config.Config, urlOverride string) error {
+	log.Println("Checking what glsync needs to sync:")
+	checked, failed := 0, 0
+	doctorChecks(ctx, cfg, urlOverride, func(result checkResult) {
+		checked++
+		switch {
+		case result.skipped:
+			log.Printf("[SKIP] %v: %v\n", result.name, result.detail)
+		case result.err != nil:
+			failed++
+			log.Printf("[FAIL] %v: %v\n", result.name, result.err)
+			if result.fix != "" {
+				log.Printf("       Fix: %v\n", result.fix)
+			}
+		default:
+			log.Printf("[PASS] %v: %v\n", result.name, result.detail)
+		}
+	})
+	if failed > 0 {
+		return fmt.Errorf("%w, %v of %v", errChecksFailed, failed, checked)
+	}
+	log.Println("Everything is ready to sync")
+	return nil
+}
+
+// Checks what a sync needs with the same code it uses, in the order a sync needs them,
+// and passes the result of each check to report once it's done
+func doctorChecks(ctx context.Context, cfg config.Config, urlOverride string, report func(checkResult)) {
+	options := checkResult{name: "Options", detail: "valid"}
+	options.err = validateConfig(cfg, true)
+	report(options)
+
+	gitInstalled := checkResult{name: "Git", fix: "Install git from https://git-scm.com/downloads and make sure it's in your PATH"}
+	gitInstalled.detail, gitInstalled.err = git.Version(ctx)
+	if gitInstalled.err != nil && cfg.GitClient == git.GoGitClient {
+		gitInstalled.err, gitInstalled.skipped = nil, true
+		gitInstalled.detail = fmt.Sprintf("isn't installed, which is fine with -%v=%v", gitClientArg, git.GoGitClient)
+	}
+	report(gitInstalled)
+
+	identity := checkResult{name: "Git identity",
+		fix: `Set them with: git config --global user.name "Your Name" && git config --global user.email "you@example.com"`}
+	repo := checkResult{name: "Repo", detail: "reachable",
+		fix: "Make sure the repo exists and that git can access it with your SSH key or credentials, e.g. by cloning it yourself"}
+	// With go-git the checks run with go-git too, as a sync does, so they don't need git
+	switch {
+	case cfg.GitClient == git.GoGitClient:
+		identity.detail, identity.err = git.CheckPureGitIdentity(cfg.RepoPath)
+	case gitInstalled.err != nil:
+		identity.skipped, identity.detail = true, "git isn't installed"
+	default:
+		identity.detail, identity.err = git.CheckIdentity(ctx, cfg.RepoPath)
+	}
+	switch {
+	case cfg.RepoPath == "" && cfg.RepoUrl == "":
+		repo.skipped, repo.detail = true, fmt.Sprintf("-%v isn't set", repoUrlArg)
+	case cfg.GitClient == git.GoGitClient && cfg.RepoPath != "":
+		repo.err = git.CheckPureGitCheckout(ctx, cfg.RepoPath)
+	case cfg.GitClient == git.GoGitClient:
+		repo.err = git.CheckPureGitRemote(ctx, cfg.RepoUrl)
+	case gitInstalled.err != nil:
+		repo.skipped, repo.detail = true, "git isn't installed"
+	case cfg.RepoPath != "":
+		repo.err = git.CheckCheckout(ctx, cfg.RepoPath)
+	default:
+		repo.err = git.CheckRemote(ctx, cfg.RepoUrl)
+	}
+	report(identity)
+	report(repo)
+
+	report(checkSession(ctx, cfg, urlOverride))
+}
+
+// Checks the LeetCode session, on leetcode.cn it also checks the CSRF and Cloudflare tokens sent with it
+func checkSession(ctx context.Context, cfg config.Config, urlOverride string) checkResult {
+	result := checkResult{name: "LeetCode session"}
+	url, err := graphqlURLOf(cfg, urlOverride)
+	if cfg.LcCookie == "" || err != nil {
+		result.skipped, result.detail = true, "the options above are invalid"
+		return result
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = doctorMaxRetries
+	}
+	session, err := code.NewLeetCode(cfg, url).CheckSession(ctx)
+	if err != nil {
+		result.err = err
+		result.fix = "Check your network connection and that the site is up, then run glsync doctor again"
+		if known, ok := findKnownError(err); ok {
+			result.fix = known.guidance
+		}
+		return result
+	}
+	result.detail = fmt.Sprintf("signed in to %v as %v", session.Site, session.Username)
+	if !session.ExpiresAt.IsZero() {
+		result.detail += fmt.Sprintf(", the cookie expires on %v", session.ExpiresAt.Format(time.DateOnly))
+	}
+	return result
+}
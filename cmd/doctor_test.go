@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/ahmed-e-abdulaziz/glsync/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctorChecksShouldCheckTheRepoWithGoGitWhenGitIsntInstalled(t *testing.T) {
+	// Given
+	repoUrl := filepath.Join(t.TempDir(), "repo.git")
+	out, err := exec.Command("git", "init", "--bare", repoUrl).CombinedOutput()
+	require.NoError(t, err, string(out))
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[user]\n\tname = Jane\n\temail = jane@example.com\n"), 0o600))
+	for _, role := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+role+"_NAME", "")
+		t.Setenv("GIT_"+role+"_EMAIL", "")
+	}
+	t.Setenv("PATH", t.TempDir()) // No git binary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBody, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(reqBody), "userStatus") {
+			w.Write([]byte(`{"data": {"userStatus": {"isSignedIn": true, "username": "jane"}}}`))
+		}
+	}))
+	defer server.Close()
+	cfg := config.Config{LcCookie: "cookie", RepoUrl: repoUrl, LcSite: "com", GitClient: git.GoGitClient}
+
+	// When
+	results := map[string]checkResult{}
+	doctorChecks(context.Background(), cfg, server.URL, func(result checkResult) {
+		results[result.name] = result
+	})
+
+	// Then
+	assert.True(t, results["Git"].skipped)
+	for _, name := range []string{"Git identity", "Repo"} {
+		assert.False(t, results[name].skipped, name)
+		assert.NoError(t, results[name].err, name)
+	}
+	assert.Equal(t, "Jane <jane@example.com>", results["Git identity"].detail)
+}
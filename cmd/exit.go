@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/ahmed-e-abdulaziz/glsync/code"
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/ahmed-e-abdulaziz/glsync/handler"
+)
+
+// Exit codes of glsync, so cron jobs and CI can tell failures apart. They are documented in the README.
+const (
+	exitFailure             = 1 // Any failure not listed below, also returned by the verify and doctor commands when a check fails
+	exitInvalidConfig       = 2 // The same code the flag package exits with on invalid flags
+	exitSessionExpired      = 3
+	exitCloudflareChallenge = 4
+	exitRateLimited         = 5
+	exitUnexpectedResponse  = 6
+	exitFetchFailed         = 7
+	exitGitFailed           = 8
+	exitPartialSync         = 9
+	exitInterrupted         = 130 // The shells' convention for a process stopped by SIGINT
+)
+
+// Returned when the options are invalid, wrapping why
+var errInvalidConfig = errors.New("invalid options")
+
+type knownError struct {
+	err      error
+	exitCode int
+	guidance string
+}
+
+// The errors glsync exits with a code of their own for, an error wrapping several of them
+// gets the code of the first one in this list
+var knownErrors = []knownError{
+	{context.Canceled, exitInterrupted,
+		"glsync was interrupted before pushing so nothing was synced, run it again to sync"},
+	{code.ErrSessionExpired, exitSessionExpired,
+		"Your LeetCode session has expired or is invalid, copy a fresh LEETCODE_SESSION cookie (and csrftoken for leetcode.cn) from your browser and pass it using -" + lcCookieArg},
+	{code.ErrCloudflareChallenge, exitCloudflareChallenge,
+		"The request was blocked by a Cloudflare challenge, visit the site in your browser and pass the fresh cf_clearance cookie using -" + lcCfClearanceArg},
+	{code.ErrRateLimited, exitRateLimited,
+		"LeetCode kept rate limiting the requests, wait a few minutes before running again or lower -" + rateLimitQuotaArg},
+	{code.ErrUnexpectedResponse, exitUnexpectedResponse,
+		"LeetCode answered with an unexpected response, its API has likely changed. Update glsync or open an issue at https://github.com/ahmed-e-abdulaziz/glsync/issues"},
+	{errInvalidConfig, exitInvalidConfig,
+		"Run glsync -h to see the options"},
+	{errRepoOutOfSync, exitFailure,
+		"Run glsync sync to commit the submissions the repo is missing"},
+	{errChecksFailed, exitFailure,
+		"Fix the failed checks above and run glsync doctor again"},
+	{handler.ErrFetchFailed, exitFetchFailed,
+		"Fetching from LeetCode kept failing, check your network connection and run glsync again. glsync doctor can help find the cause"},
+	{handler.ErrGitFailed, exitGitFailed,
+		"Run glsync doctor to check git and the access to the repo"},
+	{handler.ErrPartialSync, exitPartialSync,
+		"The other submissions went through, a sync pushed them, run glsync again to retry the ones that failed"},
+}
+
+// exitOnError is called by Execute with the error its command returned. It returns if there is none,
+// otherwise it prints the error, with the secrets of cfg redacted, and guidance on how to fix it
+// then exits with the code matching the error.
+func exitOnError(err error, cfg config.Config) {
+	if err == nil {
+		return
+	}
+	log.Printf("Error: %v\n", cfg.Redact(err.Error()))
+	known, ok := findKnownError(err)
+	if !ok {
+		os.Exit(exitFailure)
+	}
+	log.Println(known.guidance)
+	os.Exit(known.exitCode)
+}
+
+// Returns the first of knownErrors that err wraps
+func findKnownError(err error) (knownError, bool) {
+	for _, known := range knownErrors {
+		if errors.Is(err, known.err) {
+			return known, true
+		}
+	}
+	return knownError{}, false
+}
@@ -0,0 +1,99 @@
+package code
+
+import (
+	"net/http"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+)
+
+// Authenticator attaches the credentials LeetCode expects to every request sent to it
+type Authenticator interface {
+	Authenticate(req *http.Request)
+}
+
+// Picks the authenticator matching the site and the credentials in cfg:
+//   - The session is sent as a cookie and as an Authorization: Bearer header, cfg.BearerToken replaces it
+//     in the header if it's set, check https://github.com/ahmed-e-abdulaziz/glsync/issues/5 for more info
+//   - Only the session cookie is sent if cfg.CookieOnly is set
+//   - leetcode.cn needs the csrftoken and cf_clearance cookies on top of either
+func newAuthenticator(cfg config.Config, cookieDomain string) Authenticator {
+	session := cookieAuthenticator{cfg.LcCookie, cookieDomain}
+	var auth Authenticator = session
+	if !cfg.CookieOnly {
+		token := cfg.BearerToken
+		if token == "" {
+			token = cfg.LcCookie
+		}
+		auth = bearerTokenAuthenticator{session, token}
+	}
+	if cookieDomain == ".leetcode.cn" {
+		return cnAuthenticator{auth, cookieDomain, cfg.LcCsrfToken, cfg.LcCfClearance}
+	}
+	return auth
+}
+
+// cookieAuthenticator sends the LEETCODE_SESSION cookie
+type cookieAuthenticator struct {
+	session      string
+	cookieDomain string // e.g. ".leetcode.com" or ".leetcode.cn"
+}
+
+func (a cookieAuthenticator) Authenticate(req *http.Request) {
+	req.AddCookie(&http.Cookie{
+		Name:     "LEETCODE_SESSION",
+		Value:    a.session,
+		Path:     "/",
+		Domain:   a.cookieDomain,
+		HttpOnly: true,
+		MaxAge:   1209600,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   true,
+	})
+}
+
+// bearerTokenAuthenticator sends the LEETCODE_SESSION cookie and the token as an Authorization: Bearer header
+type bearerTokenAuthenticator struct {
+	cookieAuthenticator
+	token string
+}
+
+func (a bearerTokenAuthenticator) Authenticate(req *http.Request) {
+	a.cookieAuthenticator.Authenticate(req)
+	req.Header.Set("Authorization", "Bearer "+a.token)
+}
+
+// cnAuthenticator sends the session using session along with the csrftoken and cf_clearance cookies
+// and the x-csrftoken header, which leetcode.cn's CSRF middleware and Cloudflare Bot Management require
+type cnAuthenticator struct {
+	session      Authenticator
+	cookieDomain string
+	csrfToken    string
+	cfClearance  string
+}
+
+func (a cnAuthenticator) Authenticate(req *http.Request) {
+	a.session.Authenticate(req)
+	if a.csrfToken != "" {
+		req.AddCookie(&http.Cookie{
+			Name:     "csrftoken",
+			Value:    a.csrfToken,
+			Path:     "/",
+			Domain:   a.cookieDomain,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   true,
+		})
+		req.Header.Add("x-csrftoken", a.csrfToken)
+	}
+	if a.cfClearance != "" {
+		// Cloudflare sets cf_clearance after the browser solves its JS challenge.
+		// It is bound to the IP + User-Agent that solved the challenge, so
+		// browserUserAgent must match what your browser sent at that time.
+		req.AddCookie(&http.Cookie{
+			Name:   "cf_clearance",
+			Value:  a.cfClearance,
+			Path:   "/",
+			Domain: a.cookieDomain,
+			Secure: true,
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package code
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookieAuthenticatorShouldOnlySendTheSessionCookie(t *testing.T) {
+	// Given
+	auth := newAuthenticator(config.Config{LcCookie: "SESSION", CookieOnly: true}, ".leetcode.com")
+
+	// When
+	req := sendAuthenticatedRequest(t, auth)
+
+	// Then
+	assert.IsType(t, cookieAuthenticator{}, auth)
+	assert.Equal(t, map[string]string{"LEETCODE_SESSION": "SESSION"}, requestCookies(req))
+	assert.Empty(t, req.Header.Get("Authorization"))
+	assert.Empty(t, req.Header.Get("x-csrftoken"))
+}
+
+func TestAuthenticatorShouldSendTheSessionAsABearerTokenByDefault(t *testing.T) {
+	// Given
+	auth := newAuthenticator(config.Config{LcCookie: "SESSION"}, ".leetcode.com")
+
+	// When
+	req := sendAuthenticatedRequest(t, auth)
+
+	// Then
+	assert.IsType(t, bearerTokenAuthenticator{}, auth)
+	assert.Equal(t, map[string]string{"LEETCODE_SESSION": "SESSION"}, requestCookies(req))
+	assert.Equal(t, "Bearer SESSION", req.Header.Get("Authorization"))
+}
+
+func TestBearerTokenAuthenticatorShouldSendTheTokenAlongWithTheSessionCookie(t *testing.T) {
+	// Given
+	auth := newAuthenticator(config.Config{LcCookie: "SESSION", BearerToken: "TOKEN"}, ".leetcode.com")
+
+	// When
+	req := sendAuthenticatedRequest(t, auth)
+
+	// Then
+	assert.IsType(t, bearerTokenAuthenticator{}, auth)
+	assert.Equal(t, map[string]string{"LEETCODE_SESSION": "SESSION"}, requestCookies(req))
+	assert.Equal(t, "Bearer TOKEN", req.Header.Get("Authorization"))
+}
+
+func TestCnAuthenticatorShouldSendTheCsrfTokenAndCloudflareClearance(t *testing.T) {
+	// Given
+	cfg := config.Config{LcCookie: "SESSION", LcCsrfToken: "CSRF", LcCfClearance: "CLEARANCE", BearerToken: "TOKEN"}
+	auth := newAuthenticator(cfg, ".leetcode.cn")
+
+	// When
+	req := sendAuthenticatedRequest(t, auth)
+
+	// Then
+	assert.IsType(t, cnAuthenticator{}, auth)
+	assert.Equal(t, map[string]string{"LEETCODE_SESSION": "SESSION", "csrftoken": "CSRF", "cf_clearance": "CLEARANCE"}, requestCookies(req))
+	assert.Equal(t, "CSRF", req.Header.Get("x-csrftoken"))
+	assert.Equal(t, "Bearer TOKEN", req.Header.Get("Authorization"))
+}
+
+func TestCnAuthenticatorShouldOnlySendTheCookiesWhenCookieOnlyIsSet(t *testing.T) {
+	// Given
+	cfg := config.Config{LcCookie: "SESSION", LcCsrfToken: "CSRF", LcCfClearance: "CLEARANCE", CookieOnly: true}
+	auth := newAuthenticator(cfg, ".leetcode.cn")
+
+	// When
+	req := sendAuthenticatedRequest(t, auth)
+
+	// Then
+	assert.Equal(t, map[string]string{"LEETCODE_SESSION": "SESSION", "csrftoken": "CSRF", "cf_clearance": "CLEARANCE"}, requestCookies(req))
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
+
+// Sends a request authenticated by auth to a test server and returns the request the server received
+func sendAuthenticatedRequest(t *testing.T, auth Authenticator) *http.Request {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+	}))
+	defer server.Close()
+	req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+	require.NoError(t, err)
+	auth.Authenticate(req)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.NotNil(t, received)
+	return received
+}
+
+func requestCookies(req *http.Request) map[string]string {
+	cookies := map[string]string{}
+	for _, cookie := range req.Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	return cookies
+}
@@ -2,13 +2,34 @@
 // It is currently implemented by [leetcode.go]
 package code
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 const SubmissionFetchingError = "error while fetching submissions"
 const QuestionFetchingError = "error while fetching questions"
 
+// Errors a CodeClient wraps the errors it returns with, so callers can tell the user how to fix them
+var (
+	// The session cookie is expired or invalid, or the site rejected the request's credentials
+	ErrSessionExpired = errors.New("session expired or invalid")
+	// The request was blocked by a Cloudflare challenge page, the cf_clearance cookie needs to be refreshed
+	ErrCloudflareChallenge = errors.New("blocked by a Cloudflare challenge")
+	// The site kept rate limiting the requests even after slowing down and retrying
+	ErrRateLimited = errors.New("rate limited")
+	// The response didn't have the expected shape, the site's API has likely changed
+	ErrUnexpectedResponse = errors.New("unexpected response shape")
+	// The submissions of some questions couldn't be fetched, the ones of the other questions were
+	ErrPartialFetch = errors.New("some questions' submissions couldn't be fetched")
+)
+
 type CodeClient interface {
-	FetchSubmissions() ([]Submission, error)
+	// Fetches the submissions of the questions submitted after since, a zero since fetches all of them.
+	// Stops early with an error once ctx is done.
+	// If only some questions fail, the submissions of the others are returned with an error wrapping ErrPartialFetch.
+	FetchSubmissions(ctx context.Context, since time.Time) ([]Submission, error)
 }
 
 type Question struct {
@@ -20,9 +41,10 @@ type Question struct {
 
 type Submission struct {
 	Id              string
+	SubmissionId    string // The code challenge site's id of the submission itself, Id is the question's id
 	Title           string
 	TitleSlug       string
-	LastSubmittedAt time.Time
+	LastSubmittedAt time.Time // When the submission itself was made, falls back to the question's last submission time
 	Lang            string
 	Code            string
 }
@@ -0,0 +1,73 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// graphqlOperation is a GraphQL query document along with the name of the operation to run from it
+type graphqlOperation struct {
+	name  string
+	query string
+}
+
+// graphqlRequest is the JSON body of a GraphQL request, variables is marshalled from a typed struct
+// so values such as slugs containing quotes are always escaped correctly
+type graphqlRequest struct {
+	Query         string `json:"query"`
+	Variables     any    `json:"variables"`
+	OperationName string `json:"operationName"`
+}
+
+// Builds the JSON body of a request running op with the given variables
+func newGraphqlRequest(op graphqlOperation, variables any) ([]byte, error) {
+	body, err := json.Marshal(graphqlRequest{op.query, variables, op.name})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s request: %w", op.name, err)
+	}
+	return body, nil
+}
+
+// RequestBody is the JSON body of a GraphQL response, Data is null when the operation failed
+// and Errors explains why
+type RequestBody[T any] struct {
+	Data   T              `json:"data"`
+	Errors []graphqlError `json:"errors,omitempty"`
+}
+
+// graphqlError is an entry of the errors array of a GraphQL response
+type graphqlError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// graphqlErrors is returned when a GraphQL response has errors, they are retried like null responses
+// as LeetCode reports its transient failures this way too
+type graphqlErrors []graphqlError
+
+func (e graphqlErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+		if len(err.Path) > 0 {
+			messages[i] = fmt.Sprintf("%v (at %v)", err.Message, err.Path)
+		}
+	}
+	return "graphql errors: " + strings.Join(messages, "; ")
+}
+
+// Decodes the data of a GraphQL response into T
+//
+// Returns a terminal ErrUnexpectedResponse error if the body can't be decoded
+// and graphqlErrors if the response has errors.
+func decodeGraphqlResponse[T any](bodyBytes []byte) (T, error) {
+	body := RequestBody[T]{}
+	if err := json.Unmarshal(bodyBytes, &body); err != nil {
+		return body.Data, terminal(fmt.Errorf("JSON parsing error: %w: %w", ErrUnexpectedResponse, err))
+	}
+	if len(body.Errors) > 0 {
+		return body.Data, graphqlErrors(body.Errors)
+	}
+	return body.Data, nil
+}
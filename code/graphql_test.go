@@ -0,0 +1,57 @@
+package code
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGraphqlRequestShouldEscapeVariables(t *testing.T) {
+	// Given
+	variables := lcSubmissionListVariables{QuestionSlug: `slug-with-"quotes"`, Offset: 20, Limit: 20}
+
+	// When
+	body, err := newGraphqlRequest(embeddedQueries.submissionList, variables)
+
+	// Then
+	require.NoError(t, err)
+	var req struct {
+		Query         string                    `json:"query"`
+		Variables     lcSubmissionListVariables `json:"variables"`
+		OperationName string                    `json:"operationName"`
+	}
+	require.NoError(t, json.Unmarshal(body, &req))
+	assert.Equal(t, submissionListQuery, req.Query)
+	assert.Equal(t, variables, req.Variables)
+	assert.Equal(t, "submissionList", req.OperationName)
+	assert.Contains(t, string(body), `"lastKey":null`)
+	assert.NotContains(t, string(body), `"status"`)
+}
+
+func TestDecodeGraphqlResponseShouldReturnGraphqlErrors(t *testing.T) {
+	// Given
+	body := []byte(`{"data": {"submissionDetails": null}, "errors": [{"message": "Submission not found", "path": ["submissionDetails"]}]}`)
+
+	// When
+	data, err := decodeGraphqlResponse[lcSubmissionDetailsData](body)
+
+	// Then
+	var gqlErrs graphqlErrors
+	require.True(t, errors.As(err, &gqlErrs))
+	assert.Equal(t, "Submission not found", gqlErrs[0].Message)
+	assert.EqualError(t, err, "graphql errors: Submission not found (at [submissionDetails])")
+	assert.Nil(t, data.Details)
+	assert.True(t, isRetryable(err))
+}
+
+func TestDecodeGraphqlResponseShouldReturnUnexpectedResponseErrorWhenBodyIsInvalid(t *testing.T) {
+	// When
+	_, err := decodeGraphqlResponse[lcSubmissionDetailsData]([]byte(`{"data": [`))
+
+	// Then
+	assert.ErrorIs(t, err, ErrUnexpectedResponse)
+	assert.False(t, isRetryable(err))
+}
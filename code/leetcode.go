@@ -2,48 +2,73 @@ package code
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ahmed-e-abdulaziz/glsync/config"
 )
 
-//go:embed leetcode-graphql/submission-details-query.json
+//go:embed leetcode-graphql/submission-details.graphql
 var submissionDetailsQuery string
 
-//go:embed leetcode-graphql/submission-list-query.json
+//go:embed leetcode-graphql/submission-list.graphql
 var submissionListQuery string
 
-//go:embed leetcode-graphql/submission-list-cn-query.json
+//go:embed leetcode-graphql/submission-list-cn.graphql
 var submissionListQueryCN string
 
-//go:embed leetcode-graphql/submission-detail-cn-query.json
+//go:embed leetcode-graphql/submission-detail-cn.graphql
 var submissionDetailQueryCN string
 
-//go:embed leetcode-graphql/user-progress-question-list-query.json
+//go:embed leetcode-graphql/user-progress-question-list.graphql
 var userProgressQuestionListQuery string
 
+//go:embed leetcode-graphql/user-status.graphql
+var userStatusQuery string
+
+// The queries built into glsync, they can be replaced by the ones in cfg.QueriesDir
+var embeddedQueries = leetcodeQueries{
+	submissionDetails:        graphqlOperation{"submissionDetails", submissionDetailsQuery},
+	submissionList:           graphqlOperation{"submissionList", submissionListQuery},
+	submissionListCN:         graphqlOperation{"submissionList", submissionListQueryCN},
+	submissionDetailCN:       graphqlOperation{"submissionDetail", submissionDetailQueryCN},
+	userProgressQuestionList: graphqlOperation{"userProgressQuestionList", userProgressQuestionListQuery},
+	userStatus:               graphqlOperation{"globalData", userStatusQuery},
+}
+
 const (
-	maxRetry         = 25               // LeetCode API can fail A LOT :( It requires a ton of retries when it fails
-	backoffTime      = 1 * time.Second  // 1 second to avoid keep using LeetCode API when it fails
-	// empirically the cn rate-limit cooldown is ~510s (observed: cleared after 17x30s waits).
-	// We wait 520s once to clear it cleanly rather than retrying 17 times in 30s increments.
-	rateLimitBackoff = 520 * time.Second
+	maxRetry    = 25              // LeetCode API can fail A LOT :( It requires a ton of retries when it fails
+	backoffTime = 1 * time.Second // 1 second to avoid keep using LeetCode API when it fails
+	// Page size used while paging through a question's submission list
+	submissionPageSize = 20
+	// Page size used while paging through the user's questions, LeetCode may cap it lower
+	questionPageSize = 100
+	// Status of accepted submissions in leetcode.com's submission list filter
+	acceptedStatus = 10
+	// Timeout of a single LeetCode request unless cfg.RequestTimeout is set, a timed out request is retried
+	defaultRequestTimeout = 30 * time.Second
 )
 
 // Implementation of CodeClient for LeetCode
 type leetcode struct {
 	cfg          config.Config
 	graphqlUrl   string
-	cookieDomain string // e.g. ".leetcode.com" or ".leetcode.cn"
-	siteOrigin   string // e.g. "https://leetcode.com" or "https://leetcode.cn"
+	cookieDomain string       // e.g. ".leetcode.com" or ".leetcode.cn"
+	siteOrigin   string       // e.g. "https://leetcode.com" or "https://leetcode.cn"
+	limiter      *rateLimiter // Shared by copies of leetcode so concurrent workers are rate limited together
+	retry        RetryPolicy  // Used by every GraphQL request
+	httpClient   *http.Client
+	queries      leetcodeQueries
+	auth         Authenticator
 }
 
 func NewLeetCode(cfg config.Config, leetcodeGraphqlUrl string) leetcode {
@@ -53,312 +78,490 @@ func NewLeetCode(cfg config.Config, leetcodeGraphqlUrl string) leetcode {
 		cookieDomain = ".leetcode.cn"
 		siteOrigin = "https://leetcode.cn"
 	}
-	return leetcode{cfg, leetcodeGraphqlUrl, cookieDomain, siteOrigin}
+	limit := defaultRateLimits[cookieDomain]
+	if cfg.RateLimitQuota > 0 {
+		limit.quota = cfg.RateLimitQuota
+	}
+	if cfg.RateLimitWindow > 0 {
+		limit.window = cfg.RateLimitWindow
+	}
+	retry := newRetryPolicy(cfg.MaxRetries, cfg.RetryBaseDelay, cfg.RetryMaxDelay)
+	timeout := defaultRequestTimeout
+	if cfg.RequestTimeout > 0 {
+		timeout = cfg.RequestTimeout
+	}
+	httpClient := &http.Client{Timeout: timeout}
+	queries := loadQueries(cfg.QueriesDir)
+	auth := newAuthenticator(cfg, cookieDomain)
+	return leetcode{cfg, leetcodeGraphqlUrl, cookieDomain, siteOrigin, newRateLimiter(limit), retry, httpClient, queries, auth}
 }
 
 // Fetches submissions from LeetCode
 //
 // Requires cfg.LcCookie to be set correctly or will fail due to access errors
-// Returns an array of [Submission] struct
-func (lc leetcode) FetchSubmissions() ([]Submission, error) {
+// Only questions whose lastSubmittedAt is after since are fetched, unless since is zero
+// Stops and returns ctx's cause once ctx is done, including while waiting for the rate limiter or a retry
+// Returns an array of [Submission] struct, along with an error wrapping ErrPartialFetch and the errors
+// of the questions that failed if only some of them did
+func (lc leetcode) FetchSubmissions(ctx context.Context, since time.Time) ([]Submission, error) {
 	log.Println("\n==============\nFetching submissions next")
-	questions, err := lc.fetchQuestions()
+	questions, err := lc.fetchQuestions(ctx)
 	if err != nil {
 		log.Printf("Error fetching questions: %v\n", err)
-		return nil, errors.New("failed to fetch questions from LeetCode")
+		return nil, fmt.Errorf("failed to fetch questions from LeetCode: %w", err)
+	}
+	if !since.IsZero() {
+		questions = questionsSubmittedAfter(questions, since)
+		log.Printf("%v questions were submitted since the last sync at %v\n", len(questions), since.Format(time.RFC3339))
+		if len(questions) == 0 {
+			return []Submission{}, nil
+		}
 	}
 
-	log.Printf("User has %v questions accepted on LeetCode, fetching code for each next\n", len(questions))
+	log.Printf("User has %v questions accepted on LeetCode, fetching code for each next using %v workers\n",
+		len(questions), lc.concurrency())
 	submissions := make([]Submission, 0, len(questions)) // Changed to 0 initial length
 
-	for _, question := range questions {
-		log.Printf("\tFetching latest submission for question: %v %v\n", question.FrontendId, question.Title)
-		submission, err := lc.fetchQuestionSubmission(question)
-		if err != nil {
-			log.Printf("Warning: Failed to fetch submission for question %s: %v\n", question.Title, err)
+	fetchedQuestions := 0
+	var errs []error
+	// Results are collected per question index so the order doesn't depend on which worker finishes first
+	results := lc.fetchQuestionsSubmissions(ctx, questions)
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("stopped fetching submissions: %w", context.Cause(ctx))
+	}
+	for idx, questionSubmissions := range results {
+		if questionSubmissions.err != nil {
+			log.Printf("Warning: Failed to fetch submission for question %s: %v\n", questions[idx].Title, questionSubmissions.err)
+			errs = append(errs, fmt.Errorf("question %s: %w", questions[idx].Title, questionSubmissions.err))
 			continue // Skip this submission but continue with others
 		}
-		submissions = append(submissions, submission)
+		submissions = append(submissions, questionSubmissions.submissions...)
+		fetchedQuestions++
 	}
 
 	if len(submissions) == 0 {
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("failed to fetch any submissions successfully: %w", errs[0])
+		}
 		return nil, errors.New("failed to fetch any submissions successfully")
 	}
 
-	log.Printf("Fetched %d/%d submissions successfully\n==============\n", len(submissions), len(questions))
+	log.Printf("Fetched %d submissions for %d/%d questions successfully\n==============\n",
+		len(submissions), fetchedQuestions, len(questions))
+	if len(errs) > 0 {
+		return submissions, fmt.Errorf("%w, %d of %d questions failed: %w", ErrPartialFetch, len(errs), len(questions), errors.Join(errs...))
+	}
 	return submissions, nil
 }
 
-// cnRequestDelay throttles submission detail fetches on leetcode.cn.
-// Measured: 10-minute sliding window, quota of 60 requests (1 req/10s).
-// Each HTTP round-trip takes ~1s, so a 9s sleep gives ~10s total cycle,
-// staying at the safe boundary without wasting extra time.
-// Total run time: ~560 * 10s = ~95 minutes for 560 questions.
-const cnRequestDelay = 9 * time.Second
+type questionSubmissionsResult struct {
+	submissions []Submission
+	err         error
+}
 
-// New helper function to handle single question submission
-func (lc leetcode) fetchQuestionSubmission(question lcQuestion) (Submission, error) {
-	lcSubmission, err := lc.fetchSubmissionOverview(question.TitleSlug)
+// Fetches the submissions of each question using a pool of cfg.Concurrency workers
+//
+// Returns the result of each question at the same index of the question in questions.
+// No more questions are handed to the workers once ctx is done.
+func (lc leetcode) fetchQuestionsSubmissions(ctx context.Context, questions []lcQuestion) []questionSubmissionsResult {
+	results := make([]questionSubmissionsResult, len(questions))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range lc.concurrency() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				question := questions[idx]
+				if lc.cfg.AllSubmissions {
+					log.Printf("\tFetching all accepted submissions for question: %v %v\n", question.FrontendId, question.Title)
+				} else if lc.cfg.AllLanguages {
+					log.Printf("\tFetching latest submission in every language for question: %v %v\n", question.FrontendId, question.Title)
+				} else {
+					log.Printf("\tFetching latest submission for question: %v %v\n", question.FrontendId, question.Title)
+				}
+				submissions, err := lc.fetchQuestionSubmissions(ctx, question)
+				results[idx] = questionSubmissionsResult{submissions, err}
+			}
+		}()
+	}
+queueing:
+	for idx := range questions {
+		select {
+		case jobs <- idx:
+		case <-ctx.Done():
+			break queueing
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (lc leetcode) concurrency() int {
+	return max(lc.cfg.Concurrency, 1)
+}
+
+// Fetches the accepted submissions of a single question along with their code.
+//
+// Only the latest accepted submission is returned unless cfg.AllSubmissions is set,
+// in which case every accepted submission is returned ordered from oldest to newest
+// so committing them in order replays how the solution changed over time.
+// cfg.AllLanguages returns the latest accepted submission of every language instead.
+func (lc leetcode) fetchQuestionSubmissions(ctx context.Context, question lcQuestion) ([]Submission, error) {
+	lcSubmissions, err := lc.fetchSubmissionOverviews(ctx, question.TitleSlug)
 	if err != nil {
 		log.Printf("Error fetching question submissions: %v\n", err)
-		return Submission{}, errors.New("submission overview error")
+		return nil, fmt.Errorf("submission overview error: %w", err)
 	}
 
-	// Throttle requests on CN to avoid triggering the rate limiter.
-	if lc.cookieDomain == ".leetcode.cn" {
-		time.Sleep(cnRequestDelay)
-	}
+	submissions := make([]Submission, 0, len(lcSubmissions))
+	// LeetCode lists submissions newest first, iterate backwards to get them oldest first
+	for i := len(lcSubmissions) - 1; i >= 0; i-- {
+		lcSubmission := lcSubmissions[i]
 
-	code, err := lc.fetchSubmissionCode(lcSubmission.Id, 0)
-	if err != nil {
-		log.Printf("Error fetching submission code: %v\n", err)
-		return Submission{}, errors.New("submission code error")
+		code, err := lc.fetchSubmissionCode(ctx, lcSubmission.Id)
+		if err != nil {
+			log.Printf("Error fetching submission code: %v\n", err)
+			return nil, fmt.Errorf("submission code error: %w", err)
+		}
+
+		// lastSubmittedAt of the question is the last attempt which can be a failed one long after
+		// the accepted code was written, so it is only used when the submission has no timestamp
+		submittedAt := question.LastSubmittedAt
+		if !lcSubmission.Timestamp.IsZero() {
+			submittedAt = lcSubmission.Timestamp.Time
+		}
+
+		submissions = append(submissions, Submission{
+			Id:              question.FrontendId,
+			SubmissionId:    lcSubmission.Id,
+			Title:           question.Title,
+			TitleSlug:       question.TitleSlug,
+			LastSubmittedAt: submittedAt,
+			Lang:            lcSubmission.Lang,
+			Code:            code,
+		})
 	}
+	return submissions, nil
+}
 
-	return Submission{
-		question.FrontendId,
-		question.Title,
-		question.TitleSlug,
-		question.LastSubmittedAt,
-		lcSubmission.Lang,
-		code,
-	}, nil
+func questionsSubmittedAfter(questions []lcQuestion, since time.Time) []lcQuestion {
+	filtered := make([]lcQuestion, 0, len(questions))
+	for _, question := range questions {
+		if question.LastSubmittedAt.After(since) {
+			filtered = append(filtered, question)
+		}
+	}
+	return filtered
 }
 
 // Fetches question to extract required info for Submission struct
 // Uses LC's GraphQl query that's called userProgressQuestionList
-func (lc leetcode) fetchQuestions() ([]lcQuestion, error) {
-	bodyBytes, err := lc.queryLeetcode(userProgressQuestionListQuery)
+//
+// Pages through the questions questionPageSize at a time until totalNum questions are fetched.
+// LeetCode may return fewer questions than requested, so the next page always starts after
+// the questions actually received. It stops at a page without new questions too, in case
+// LeetCode ignores skip and keeps returning the same page. A failed page is retried using lc.retry.
+func (lc leetcode) fetchQuestions(ctx context.Context) ([]lcQuestion, error) {
+	var (
+		questions []lcQuestion
+		seenSlugs = map[string]bool{}
+		skip      int
+	)
+	for {
+		var page lcUserProgressQuestionList
+		err := lc.retry.Do(ctx, fmt.Sprintf("fetching questions page at %d", skip), func() (err error) {
+			page, err = lc.fetchQuestionsPage(ctx, skip)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		fetched := len(questions)
+		for _, question := range page.Questions {
+			// The list can shift while paging if a question is submitted meanwhile, so skip duplicates
+			if seenSlugs[question.TitleSlug] {
+				continue
+			}
+			seenSlugs[question.TitleSlug] = true
+			questions = append(questions, question)
+		}
+		skip += len(page.Questions)
+		if len(questions) == fetched || (page.TotalNum > 0 && skip >= page.TotalNum) ||
+			(page.TotalNum == 0 && len(page.Questions) < questionPageSize) {
+			break
+		}
+	}
+	return questions, nil
+}
+
+// Fetches a single page of up to questionPageSize questions starting at skip
+func (lc leetcode) fetchQuestionsPage(ctx context.Context, skip int) (lcUserProgressQuestionList, error) {
+	variables := lcUserProgressQuestionListVariables{
+		Filters: lcUserProgressQuestionListFilters{QuestionStatus: "SOLVED", Skip: skip, Limit: questionPageSize},
+	}
+	bodyBytes, err := lc.queryLeetcode(ctx, lc.queries.userProgressQuestionList, variables)
 	if err != nil {
 		log.Println(err)
-		return nil, fmt.Errorf("error fetching user questions from leetcode: %w", err)
+		return lcUserProgressQuestionList{}, fmt.Errorf("error fetching user questions from leetcode: %w", err)
 	}
-	body := &RequestBody[lcUserProgressQuestionListData]{}
-	err = json.Unmarshal(bodyBytes, body)
+	data, err := decodeGraphqlResponse[lcUserProgressQuestionListData](bodyBytes)
 	if err != nil {
 		log.Println(err)
-		return nil, fmt.Errorf("error parsing user questions response from leetcode: %w", err)
+		return lcUserProgressQuestionList{}, fmt.Errorf("error parsing user questions response from leetcode: %w", err)
 	}
-	return body.Data.QuestionsList.Questions, nil
+	if data.QuestionsList == nil {
+		return lcUserProgressQuestionList{}, terminal(fmt.Errorf("user questions response from leetcode has no userProgressQuestionList: %w", ErrUnexpectedResponse))
+	}
+	return *data.QuestionsList, nil
 }
 
-// Fetches id and language of submission into lcSubmissionOverview struct
+// Fetches id and language of accepted submissions into lcSubmissionOverview structs
 // Uses LC's GraphQl query that's called submissionList
 //
 // titleSlug is a no-whitespace representation of the question title, used to query submissions for a question
-// Returns an error if it encounters one while querying and an nil lcSumbissionOverview
-func (lc leetcode) fetchSubmissionOverview(titleSlug string) (lcSumbissionOverview, error) {
+// Pages through the submission list using lastKey/hasNext when cfg.AllSubmissions or cfg.AllLanguages is set,
+// otherwise it stops at the first accepted submission which is the latest one.
+// With only cfg.AllLanguages set it keeps the latest accepted submission of each language.
+// Returns the submissions newest first, or an error if it encounters one while querying
+func (lc leetcode) fetchSubmissionOverviews(ctx context.Context, titleSlug string) ([]lcSumbissionOverview, error) {
 	var (
-		bodyBytes   []byte
-		err         error
-		submissions []lcSumbissionOverview
+		accepted  []lcSumbissionOverview
+		seenLangs = map[string]bool{}
+		offset    int
+		lastKey   *string
 	)
+	for {
+		var page lcSubmissionList
+		err := lc.retry.Do(ctx, "fetching submissions of question "+titleSlug, func() (err error) {
+			page, err = lc.fetchSubmissionListPage(ctx, titleSlug, offset, lastKey)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, submission := range page.LCSubmissions {
+			if !submission.isAccepted() {
+				continue
+			}
+			if !lc.cfg.AllSubmissions && seenLangs[submission.Lang] {
+				continue // a newer accepted submission in this language was already kept
+			}
+			seenLangs[submission.Lang] = true
+			accepted = append(accepted, submission)
+			if !lc.cfg.AllSubmissions && !lc.cfg.AllLanguages {
+				return accepted, nil // we only need the latest accepted submission
+			}
+		}
+		if !page.HasNext || len(page.LCSubmissions) == 0 {
+			break
+		}
+		offset += len(page.LCSubmissions)
+		lastKey = page.LastKey
+	}
+
+	if len(accepted) == 0 {
+		return nil, fmt.Errorf("no submissions found for question: %s", titleSlug)
+	}
+	return accepted, nil
+}
+
+// Fetches a single page of the submission list of a question starting at offset/lastKey
+func (lc leetcode) fetchSubmissionListPage(ctx context.Context, titleSlug string, offset int, lastKey *string) (lcSubmissionList, error) {
+	variables := lcSubmissionListVariables{QuestionSlug: titleSlug, Offset: offset, Limit: submissionPageSize, LastKey: lastKey}
 
 	if lc.cookieDomain == ".leetcode.cn" {
 		// leetcode.cn uses "submissionList" field; leetcode.com uses "questionSubmissionList"
-		bodyBytes, err = lc.queryLeetcode(fmt.Sprintf(submissionListQueryCN, titleSlug))
+		bodyBytes, err := lc.queryLeetcode(ctx, lc.queries.submissionListCN, variables)
 		if err != nil {
-			return lcSumbissionOverview{}, fmt.Errorf("error fetching submission overview from leetcode: %w", err)
-		}
-		body := &RequestBody[lcSubmissionListDataCN]{}
-		if err = json.Unmarshal(bodyBytes, body); err != nil {
-			log.Println(err)
-			return lcSumbissionOverview{}, fmt.Errorf("error parsing submission overview from leetcode: %w", err)
+			return lcSubmissionList{}, fmt.Errorf("error fetching submission overview from leetcode: %w", err)
 		}
-		submissions = body.Data.LCSubmissionList.LCSubmissions
-	} else {
-		bodyBytes, err = lc.queryLeetcode(fmt.Sprintf(submissionListQuery, titleSlug))
+		data, err := decodeGraphqlResponse[lcSubmissionListDataCN](bodyBytes)
 		if err != nil {
-			return lcSumbissionOverview{}, fmt.Errorf("error fetching submission overview from leetcode: %w", err)
-		}
-		body := &RequestBody[lcSubmissionListData]{}
-		if err = json.Unmarshal(bodyBytes, body); err != nil {
 			log.Println(err)
-			return lcSumbissionOverview{}, fmt.Errorf("error parsing submission overview from leetcode: %w", err)
+			return lcSubmissionList{}, fmt.Errorf("error parsing submission overview from leetcode: %w", err)
 		}
-		submissions = body.Data.LCSubmissionList.LCSubmissions
+		if data.LCSubmissionList == nil {
+			return lcSubmissionList{}, terminal(fmt.Errorf("submission overview from leetcode has no submissionList: %w", ErrUnexpectedResponse))
+		}
+		return *data.LCSubmissionList, nil
 	}
 
-	if len(submissions) == 0 {
-		return lcSumbissionOverview{}, fmt.Errorf("no submissions found for question: %s", titleSlug)
+	variables.Status = acceptedStatus
+	bodyBytes, err := lc.queryLeetcode(ctx, lc.queries.submissionList, variables)
+	if err != nil {
+		return lcSubmissionList{}, fmt.Errorf("error fetching submission overview from leetcode: %w", err)
+	}
+	data, err := decodeGraphqlResponse[lcSubmissionListData](bodyBytes)
+	if err != nil {
+		log.Println(err)
+		return lcSubmissionList{}, fmt.Errorf("error parsing submission overview from leetcode: %w", err)
+	}
+	if data.LCSubmissionList == nil {
+		return lcSubmissionList{}, terminal(fmt.Errorf("submission overview from leetcode has no questionSubmissionList: %w", ErrUnexpectedResponse))
 	}
-	return submissions[0], nil // we only need the latest accepted submission
+	return *data.LCSubmissionList, nil
 }
 
 // Fetches submission's code using the leetcode's submission id.
 // On leetcode.cn uses submissionDetail (singular); on leetcode.com uses submissionDetails (plural).
+// Null responses, empty code and network errors are retried using lc.retry.
 // Returns an empty string and an error if it encounters one while querying.
-func (lc leetcode) fetchSubmissionCode(id string, retry int) (string, error) {
-	if lc.cookieDomain == ".leetcode.cn" {
-		return lc.fetchSubmissionCodeCN(id, retry)
+func (lc leetcode) fetchSubmissionCode(ctx context.Context, id string) (string, error) {
+	var code string
+	err := lc.retry.Do(ctx, "fetching code of submission "+id, func() (err error) {
+		if lc.cookieDomain == ".leetcode.cn" {
+			code, err = lc.fetchSubmissionCodeCN(ctx, id)
+		} else {
+			code, err = lc.fetchSubmissionCodeCOM(ctx, id)
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
 	}
-	return lc.fetchSubmissionCodeCOM(id, retry)
+	return code, nil
 }
 
-func (lc leetcode) fetchSubmissionCodeCOM(id string, retry int) (string, error) {
-	bodyBytes, err := lc.queryLeetcode(fmt.Sprintf(submissionDetailsQuery, id))
+func (lc leetcode) fetchSubmissionCodeCOM(ctx context.Context, id string) (string, error) {
+	submissionId, err := strconv.Atoi(id)
 	if err != nil {
-		if retry < maxRetry {
-			log.Printf("Network error, retry %d/%d after %v\n", retry+1, maxRetry, backoffTime)
-			time.Sleep(backoffTime)
-			return lc.fetchSubmissionCodeCOM(id, retry+1)
-		}
-		return "", fmt.Errorf("max retries reached for network error: %w", err)
+		return "", terminal(fmt.Errorf("invalid submission id %q: %w", id, err))
 	}
-
-	body := &RequestBody[lcSubmissionDetailsData]{}
-	if err := json.Unmarshal(bodyBytes, body); err != nil {
-		return "", fmt.Errorf("JSON parsing error: %w", err)
+	bodyBytes, err := lc.queryLeetcode(ctx, lc.queries.submissionDetails, lcSubmissionDetailsVariables{submissionId})
+	if err != nil {
+		return "", err
 	}
 
-	if body.Data.Details == nil {
-		if retry < maxRetry {
-			log.Printf("Null response, retry %d/%d after %v\n", retry+1, maxRetry, backoffTime)
-			time.Sleep(backoffTime)
-			return lc.fetchSubmissionCodeCOM(id, retry+1)
-		}
-		log.Printf("Warning: Max retries reached, consistently getting null response for submission %s", id)
-		return "", fmt.Errorf("max retries reached for null response%s", id)
+	data, err := decodeGraphqlResponse[lcSubmissionDetailsData](bodyBytes)
+	if err != nil {
+		return "", err
 	}
-
-	if len(body.Data.Details.Code) == 0 {
-		if retry < maxRetry {
-			log.Printf("Empty code, retry %d/%d after %v\n", retry+1, maxRetry, backoffTime)
-			time.Sleep(backoffTime)
-			return lc.fetchSubmissionCodeCOM(id, retry+1)
-		}
-		log.Printf("Warning: Max retries reached with empty code for submission %s", id)
-		return "", fmt.Errorf("max retries reached for empty code")
+	if data.Details == nil {
+		return "", errNullResponse
 	}
-
-	return body.Data.Details.Code, nil
+	if len(data.Details.Code) == 0 {
+		return "", errEmptyCode
+	}
+	return data.Details.Code, nil
 }
 
-func (lc leetcode) fetchSubmissionCodeCN(id string, retry int) (string, error) {
-	bodyBytes, err := lc.queryLeetcode(fmt.Sprintf(submissionDetailQueryCN, id))
+func (lc leetcode) fetchSubmissionCodeCN(ctx context.Context, id string) (string, error) {
+	bodyBytes, err := lc.queryLeetcode(ctx, lc.queries.submissionDetailCN, lcSubmissionDetailVariablesCN{id})
 	if err != nil {
-		if retry < maxRetry {
-			log.Printf("Network error, retry %d/%d after %v\n", retry+1, maxRetry, backoffTime)
-			time.Sleep(backoffTime)
-			return lc.fetchSubmissionCodeCN(id, retry+1)
-		}
-		return "", fmt.Errorf("max retries reached for network error: %w", err)
+		return "", err
 	}
 
-	// leetcode.cn rate-limits with a JSON-escaped Chinese message. The raw response
-	// body contains literal \uXXXX sequences, not decoded UTF-8, so we match the
-	// escaped form. "超出访问限制" = \u8d85\u51fa\u8bbf\u95ee\u9650\u5236
-	if strings.Contains(string(bodyBytes), `\u8d85\u51fa\u8bbf\u95ee\u9650\u5236`) {
-		if retry < maxRetry {
-			log.Printf("Rate limit hit, retry %d/%d after %v\n", retry+1, maxRetry, rateLimitBackoff)
-			time.Sleep(rateLimitBackoff)
-			return lc.fetchSubmissionCodeCN(id, retry+1)
-		}
-		return "", fmt.Errorf("max retries reached for CN rate limit for id=%s", id)
-	}
-
-	body := &RequestBody[lcSubmissionDetailDataCN]{}
-	if err := json.Unmarshal(bodyBytes, body); err != nil {
-		return "", fmt.Errorf("JSON parsing error: %w", err)
+	data, err := decodeGraphqlResponse[lcSubmissionDetailDataCN](bodyBytes)
+	if err != nil {
+		return "", err
 	}
-
-	if body.Data.Detail == nil {
-		if retry < maxRetry {
-			log.Printf("Null response, retry %d/%d after %v\n", retry+1, maxRetry, backoffTime)
-			time.Sleep(backoffTime)
-			return lc.fetchSubmissionCodeCN(id, retry+1)
-		}
-		return "", fmt.Errorf("max retries reached for null CN submissionDetail response for id=%s", id)
+	if data.Detail == nil {
+		return "", errNullResponse
 	}
-
-	if len(body.Data.Detail.Code) == 0 {
-		if retry < maxRetry {
-			log.Printf("Empty code, retry %d/%d after %v\n", retry+1, maxRetry, backoffTime)
-			time.Sleep(backoffTime)
-			return lc.fetchSubmissionCodeCN(id, retry+1)
-		}
-		return "", fmt.Errorf("max retries reached for empty CN code for submission %s", id)
+	if len(data.Detail.Code) == 0 {
+		return "", errEmptyCode
 	}
+	return data.Detail.Code, nil
+}
 
-	return body.Data.Detail.Code, nil
+// leetcode.cn rate-limits with a JSON-escaped Chinese message. The raw response
+// body contains literal \uXXXX sequences, not decoded UTF-8, so we match the
+// escaped form. "超出访问限制" = \u8d85\u51fa\u8bbf\u95ee\u9650\u5236
+func isRateLimitedResponse(bodyBytes []byte) bool {
+	return bytes.Contains(bodyBytes, []byte(`\u8d85\u51fa\u8bbf\u95ee\u9650\u5236`))
 }
 
-// queryLeetcode sends the query string to leetcode's GraphQL URL.
+// queryLeetcode sends a request running op with the given variables to leetcode's GraphQL URL.
 //
 // On success it returns the resulting bytes of the response body and a nil error.
 // Otherwise it will return nil and any error it faces while creating the request
-// or while communicating with LC.
-func (lc leetcode) queryLeetcode(query string) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodPost, lc.graphqlUrl, bytes.NewBuffer([]byte(query)))
+// or while communicating with LC. The request is aborted once ctx is done or lc.httpClient's timeout passes.
+//
+// Every request waits on lc.limiter first, which is shared by all the workers so together they don't
+// trigger LeetCode's rate limiter, and slows it down when LeetCode answers that its rate limit was hit.
+func (lc leetcode) queryLeetcode(ctx context.Context, op graphqlOperation, variables any) ([]byte, error) {
+	query, err := newGraphqlRequest(op, variables)
+	if err != nil {
+		return nil, terminal(err)
+	}
+	if err := lc.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lc.graphqlUrl, bytes.NewBuffer(query))
 	if err != nil {
 		return nil, err
 	}
 	lc.addCookieAndHeaders(req)
-	res, err := http.DefaultClient.Do(req)
+	res, err := lc.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
-	bodyBytes, _ := io.ReadAll(res.Body)
+	bodyBytes, err := io.ReadAll(res.Body)
+	if err != nil { // e.g. the timeout passed while reading the body
+		return nil, err
+	}
+	if res.StatusCode == http.StatusTooManyRequests || isRateLimitedResponse(bodyBytes) {
+		lc.limiter.OnRateLimited()
+		log.Printf("Rate limit hit, slowing down to a request every %v\n", lc.limiter.interval())
+		return nil, fmt.Errorf("%w by leetcode (HTTP %d) from %s", ErrRateLimited, res.StatusCode, lc.graphqlUrl)
+	}
 	// A non-JSON response (e.g. HTML error page) would cause confusing downstream
 	// JSON parse errors; surface the HTTP status and a snippet here instead.
-	if len(bodyBytes) > 0 && bodyBytes[0] != '{' && bodyBytes[0] != '[' {
-		preview := string(bodyBytes)
+	// Authentication failures are surfaced the same way so they aren't retried.
+	isJson := len(bodyBytes) > 0 && (bodyBytes[0] == '{' || bodyBytes[0] == '[')
+	if (len(bodyBytes) > 0 && !isJson) ||
+		res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		// Error pages can echo the request's cookies back, so they are redacted before the preview is logged
+		preview := lc.cfg.Redact(string(bodyBytes))
 		if len(preview) > 300 {
 			preview = preview[:300] + "..."
 		}
-		return nil, fmt.Errorf("unexpected non-JSON response (HTTP %d) from %s: %s",
-			res.StatusCode, lc.graphqlUrl, preview)
+		return nil, &httpStatusError{res.StatusCode, lc.graphqlUrl, preview, classifyErrorResponse(res, bodyBytes)}
 	}
+	lc.limiter.OnSuccess()
 	return bodyBytes, nil
 }
 
+// Markers found in the pages Cloudflare serves instead of the API response when it challenges a request
+var cloudflareChallengeMarkers = [][]byte{
+	[]byte("Just a moment..."),
+	[]byte("Attention Required! | Cloudflare"),
+	[]byte("cf-chl"),
+	[]byte("cf_chl"),
+	[]byte("challenge-platform"),
+}
+
+// classifyErrorResponse tells a Cloudflare challenge page apart from LeetCode rejecting the session.
+// Returns nil if the response is neither, e.g. a server error page.
+func classifyErrorResponse(res *http.Response, bodyBytes []byte) error {
+	if res.Header.Get("cf-mitigated") == "challenge" {
+		return ErrCloudflareChallenge
+	}
+	for _, marker := range cloudflareChallengeMarkers {
+		if bytes.Contains(bodyBytes, marker) {
+			return ErrCloudflareChallenge
+		}
+	}
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
 // browserUserAgent is a standard Chrome UA sent with every request.
 // Cloudflare and leetcode.cn both fingerprint the User-Agent; the Go default
 // ("Go-http-client/2.0") is immediately flagged as a bot.
 const browserUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) " +
 	"AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
 
-// Adds cfg.LcCookie cookie and necessary headers to req.
-// For leetcode.cn, also attaches the csrftoken and cf_clearance cookies,
-// x-csrftoken header, and the Referer/Origin headers required by Django's
-// CSRF middleware and Cloudflare Bot Management.
+// Adds the credentials of lc.auth and the headers LeetCode expects from a browser to req.
+// The Referer/Origin headers are required by Django's CSRF middleware.
 func (lc leetcode) addCookieAndHeaders(req *http.Request) {
-	req.AddCookie(&http.Cookie{
-		Name:     "LEETCODE_SESSION",
-		Value:    lc.cfg.LcCookie,
-		Path:     "/",
-		Domain:   lc.cookieDomain,
-		HttpOnly: true,
-		MaxAge:   1209600,
-		SameSite: http.SameSiteLaxMode,
-		Secure:   true,
-	})
-	if lc.cfg.LcCsrfToken != "" {
-		req.AddCookie(&http.Cookie{
-			Name:     "csrftoken",
-			Value:    lc.cfg.LcCsrfToken,
-			Path:     "/",
-			Domain:   lc.cookieDomain,
-			SameSite: http.SameSiteLaxMode,
-			Secure:   true,
-		})
-		req.Header.Add("x-csrftoken", lc.cfg.LcCsrfToken)
-	}
-	if lc.cfg.LcCfClearance != "" {
-		// Cloudflare sets cf_clearance after the browser solves its JS challenge.
-		// It is bound to the IP + User-Agent that solved the challenge, so
-		// browserUserAgent must match what your browser sent at that time.
-		req.AddCookie(&http.Cookie{
-			Name:   "cf_clearance",
-			Value:  lc.cfg.LcCfClearance,
-			Path:   "/",
-			Domain: lc.cookieDomain,
-			Secure: true,
-		})
-	}
+	lc.auth.Authenticate(req)
 	req.Header.Set("User-Agent", browserUserAgent)
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", lc.cfg.LcCookie))
 	req.Header.Add("Connection", "keep-alive")
 	req.Header.Add("Content-type", "application/json")
 	// Django's CsrfViewMiddleware validates Referer against Origin for HTTPS
@@ -367,15 +570,42 @@ func (lc leetcode) addCookieAndHeaders(req *http.Request) {
 	req.Header.Add("Origin", lc.siteOrigin)
 }
 
-type RequestBody[T any] struct {
-	Data T `json:"data"`
+// Variables of the userProgressQuestionList query
+type lcUserProgressQuestionListVariables struct {
+	Filters lcUserProgressQuestionListFilters `json:"filters"`
+}
+
+type lcUserProgressQuestionListFilters struct {
+	QuestionStatus string `json:"questionStatus"`
+	Skip           int    `json:"skip"`
+	Limit          int    `json:"limit"`
+}
+
+// Variables of the submissionList queries, Status is only supported by leetcode.com
+type lcSubmissionListVariables struct {
+	QuestionSlug string  `json:"questionSlug"`
+	Offset       int     `json:"offset"`
+	Limit        int     `json:"limit"`
+	LastKey      *string `json:"lastKey"` // null for the first page
+	Status       int     `json:"status,omitempty"`
+}
+
+// Variables of the submissionDetails query
+type lcSubmissionDetailsVariables struct {
+	SubmissionId int `json:"submissionId"`
+}
+
+// Variables of the leetcode.cn submissionDetail query
+type lcSubmissionDetailVariablesCN struct {
+	Id string `json:"id"`
 }
 
 type lcUserProgressQuestionListData struct {
-	QuestionsList lcUserProgressQuestionList `json:"userProgressQuestionList"`
+	QuestionsList *lcUserProgressQuestionList `json:"userProgressQuestionList"`
 }
 
 type lcUserProgressQuestionList struct {
+	TotalNum  int          `json:"totalNum"`
 	Questions []lcQuestion `json:"questions"`
 }
 
@@ -389,22 +619,32 @@ type lcQuestion struct {
 }
 
 type lcSubmissionListData struct {
-	LCSubmissionList lcSubmissionList `json:"questionSubmissionList"`
+	LCSubmissionList *lcSubmissionList `json:"questionSubmissionList"`
 }
 
 // lcSubmissionListDataCN is used for leetcode.cn whose GraphQL schema exposes
 // the field as "submissionList" instead of "questionSubmissionList".
 type lcSubmissionListDataCN struct {
-	LCSubmissionList lcSubmissionList `json:"submissionList"`
+	LCSubmissionList *lcSubmissionList `json:"submissionList"`
 }
 
 type lcSubmissionList struct {
+	LastKey       *string                `json:"lastKey"`
+	HasNext       bool                   `json:"hasNext"`
 	LCSubmissions []lcSumbissionOverview `json:"submissions"`
 }
 
 type lcSumbissionOverview struct {
-	Id   string `json:"id"`
-	Lang string `json:"lang"`
+	Id            string      `json:"id"`
+	Lang          string      `json:"lang"`
+	StatusDisplay string      `json:"statusDisplay"`
+	Timestamp     lcTimestamp `json:"timestamp"`
+}
+
+// leetcode.com filters the submission list by status already, leetcode.cn doesn't
+// so it returns failed attempts as well and they have to be filtered out here.
+func (s lcSumbissionOverview) isAccepted() bool {
+	return s.StatusDisplay == "" || s.StatusDisplay == "Accepted"
 }
 
 type lcSubmissionDetailsData struct {
@@ -420,3 +660,30 @@ type lcSubmissionDetails struct {
 type lcSubmissionDetailDataCN struct {
 	Detail *lcSubmissionDetails `json:"submissionDetail"`
 }
+
+// lcTimestamp is a unix timestamp in seconds as returned by LeetCode's submission APIs.
+// leetcode.com sends it as a string (e.g. "1735406731") while other queries send a number,
+// so both forms are accepted. A null or missing timestamp is left as the zero time.
+type lcTimestamp struct {
+	time.Time
+}
+
+func (t *lcTimestamp) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	seconds, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid submission timestamp %s: %w", data, err)
+	}
+	t.Time = time.Unix(seconds, 0).UTC()
+	return nil
+}
+
+func (t lcTimestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(strconv.FormatInt(t.Unix(), 10))), nil
+}
@@ -1,16 +1,22 @@
 package code
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ahmed-e-abdulaziz/glsync/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //go:embed leetcode-testdata/leetcode-responses/question-submission-list-response.json
@@ -30,6 +36,7 @@ var (
 
 var (
 	lc             leetcode
+	testUrl        string
 	currentHandler func(w http.ResponseWriter, reqBody string)
 )
 
@@ -38,8 +45,11 @@ func TestMain(m *testing.M) {
 		reqBody, _ := io.ReadAll(r.Body)
 		currentHandler(w, string(reqBody))
 	}))
-	testUrl := "http://" + server.Listener.Addr().String()
-	cfg := config.Config{LcCookie: "COOKIE", RepoUrl: "REPO_URL"}
+	testUrl = "http://" + server.Listener.Addr().String()
+	cfg := config.Config{
+		LcCookie: "COOKIE", RepoUrl: "REPO_URL",
+		RetryBaseDelay: time.Millisecond, RetryMaxDelay: time.Millisecond, RateLimitWindow: time.Millisecond,
+	}
 	lc = NewLeetCode(cfg, testUrl)
 	m.Run()
 }
@@ -71,7 +81,7 @@ func TestFetchSubmissions(t *testing.T) {
 	}
 
 	// When
-	res, _ := lc.FetchSubmissions()
+	res, _ := lc.FetchSubmissions(context.Background(), time.Time{})
 	submission := res[0]
 
 	// Then
@@ -83,6 +93,288 @@ func TestFetchSubmissions(t *testing.T) {
 	assert.True(t, submissionDetailsCalled)
 }
 
+func TestFetchSubmissionsWithAllSubmissionsShouldPageThroughHistoryOldestFirst(t *testing.T) {
+	// Given
+	allSubmissionsLc := NewLeetCode(config.Config{LcCookie: "COOKIE", RepoUrl: "REPO_URL", AllSubmissions: true}, testUrl)
+	submissionListPages := 0
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			w.Write(userProgressQuestionListResponse)
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			submissionListPages++
+			page := lcSubmissionList{
+				HasNext:       true,
+				LastKey:       stringPtr("second-page"),
+				LCSubmissions: []lcSumbissionOverview{{Id: "3", Lang: "golang"}, {Id: "2", Lang: "golang"}},
+			}
+			if lastKey := requestVariables[lcSubmissionListVariables](t, reqBody).LastKey; lastKey != nil && *lastKey == "second-page" {
+				page = lcSubmissionList{LCSubmissions: []lcSumbissionOverview{{Id: "1", Lang: "java"}}}
+			}
+			json.NewEncoder(w).Encode(RequestBody[lcSubmissionListData]{Data: lcSubmissionListData{&page}})
+		}
+		if strings.Contains(reqBody, "submissionDetails") {
+			id := requestVariables[lcSubmissionDetailsVariables](t, reqBody).SubmissionId
+			json.NewEncoder(w).Encode(RequestBody[lcSubmissionDetailsData]{
+				Data: lcSubmissionDetailsData{&lcSubmissionDetails{Code: "code of submission " + strconv.Itoa(id)}},
+			})
+		}
+	}
+
+	// When
+	res, err := allSubmissionsLc.FetchSubmissions(context.Background(), time.Time{})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, submissionListPages)
+	assert.Len(t, res, 3)
+	for idx, expectedId := range []string{"1", "2", "3"} {
+		assert.Equal(t, expectedId, res[idx].SubmissionId)
+		assert.Equal(t, "code of submission "+expectedId, res[idx].Code)
+		assert.Equal(t, "128", res[idx].Id)
+	}
+	assert.Equal(t, "java", res[0].Lang)
+}
+
+func TestFetchSubmissionsWithAllLanguagesShouldKeepLatestSubmissionOfEachLanguage(t *testing.T) {
+	// Given
+	allLanguagesLc := NewLeetCode(config.Config{LcCookie: "COOKIE", RepoUrl: "REPO_URL", AllLanguages: true}, testUrl)
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			w.Write(userProgressQuestionListResponse)
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			w.Write([]byte(`{"data": {"questionSubmissionList": {"hasNext": false, "submissions": [
+				{"id": "4", "lang": "golang"},
+				{"id": "3", "lang": "java"},
+				{"id": "2", "lang": "golang"},
+				{"id": "1", "lang": "java"}
+			]}}}`))
+		}
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.Write(submissionDetailsResponse)
+		}
+	}
+
+	// When
+	res, err := allLanguagesLc.FetchSubmissions(context.Background(), time.Time{})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "3", res[0].SubmissionId)
+	assert.Equal(t, "java", res[0].Lang)
+	assert.Equal(t, "4", res[1].SubmissionId)
+	assert.Equal(t, "golang", res[1].Lang)
+}
+
+func TestFetchSubmissionsShouldUseSubmissionTimestampAndFallbackToQuestionDate(t *testing.T) {
+	// Given
+	allSubmissionsLc := NewLeetCode(config.Config{LcCookie: "COOKIE", RepoUrl: "REPO_URL", AllSubmissions: true}, testUrl)
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			w.Write(userProgressQuestionListResponse)
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			// Newest submission has a timestamp as a string like leetcode.com sends it, the older one has none
+			w.Write([]byte(`{"data": {"questionSubmissionList": {"hasNext": false, "submissions": [
+				{"id": "2", "lang": "golang", "timestamp": "1700000000"},
+				{"id": "1", "lang": "golang", "timestamp": null}
+			]}}}`))
+		}
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.Write(submissionDetailsResponse)
+		}
+	}
+
+	// When
+	res, err := allSubmissionsLc.FetchSubmissions(context.Background(), time.Time{})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	questionLastSubmittedAt, _ := time.Parse(time.RFC3339, "2024-12-28T17:25:31+00:00")
+	assert.True(t, questionLastSubmittedAt.Equal(res[0].LastSubmittedAt))
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), res[1].LastSubmittedAt)
+}
+
+func TestFetchSubmissionsShouldSkipQuestionsNotSubmittedSinceTheLastSync(t *testing.T) {
+	// Given
+	submissionListCalledSinceLastSync := false
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			w.Write(userProgressQuestionListResponse)
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			submissionListCalledSinceLastSync = true
+		}
+	}
+	lastSync, _ := time.Parse(time.RFC3339, "2025-01-01T00:00:00+00:00") // After the question's lastSubmittedAt
+
+	// When
+	res, err := lc.FetchSubmissions(context.Background(), lastSync)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+	assert.False(t, submissionListCalledSinceLastSync)
+}
+
+func TestFetchSubmissionsShouldPageThroughQuestionsAndRetryAFailedPage(t *testing.T) {
+	// Given
+	questionPages := map[string]int{}
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			// LeetCode caps the page to 2 questions although more were requested
+			questions := []lcQuestion{{FrontendId: "1", TitleSlug: "two-sum"}, {FrontendId: "2", TitleSlug: "add-two-numbers"}}
+			skip := "0"
+			if requestVariables[lcUserProgressQuestionListVariables](t, reqBody).Filters.Skip == 2 {
+				skip = "2"
+				questions = []lcQuestion{{FrontendId: "3", TitleSlug: "longest-substring"}}
+			}
+			questionPages[skip]++
+			if skip == "2" && questionPages[skip] == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte("<html>Bad Gateway</html>"))
+				return
+			}
+			json.NewEncoder(w).Encode(RequestBody[lcUserProgressQuestionListData]{
+				Data: lcUserProgressQuestionListData{&lcUserProgressQuestionList{TotalNum: 3, Questions: questions}},
+			})
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			w.Write(questionSubmissionListResponse)
+		}
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.Write(submissionDetailsResponse)
+		}
+	}
+
+	// When
+	res, err := lc.FetchSubmissions(context.Background(), time.Time{})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"0": 1, "2": 2}, questionPages)
+	assert.Len(t, res, 3)
+	assert.Equal(t, "3", res[2].Id)
+}
+
+func TestFetchSubmissionsShouldStopPagingWhenAPageHasNoNewQuestions(t *testing.T) {
+	// Given
+	questions := make([]lcQuestion, questionPageSize)
+	for idx := range questions {
+		questions[idx] = lcQuestion{FrontendId: strconv.Itoa(idx), TitleSlug: "question-" + strconv.Itoa(idx)}
+	}
+	questionPages := 0
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			// Ignores skip and doesn't report the total, so every page is the same full page
+			questionPages++
+			json.NewEncoder(w).Encode(RequestBody[lcUserProgressQuestionListData]{
+				Data: lcUserProgressQuestionListData{&lcUserProgressQuestionList{Questions: questions}},
+			})
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			w.Write(questionSubmissionListResponse)
+		}
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.Write(submissionDetailsResponse)
+		}
+	}
+
+	// When
+	res, err := lc.FetchSubmissions(context.Background(), time.Time{})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, questionPages)
+	assert.Len(t, res, questionPageSize)
+}
+
+func TestFetchSubmissionsConcurrentlyShouldKeepQuestionsOrder(t *testing.T) {
+	// Given
+	concurrentLc := NewLeetCode(config.Config{LcCookie: "COOKIE", RepoUrl: "REPO_URL", Concurrency: 4, RateLimitWindow: time.Millisecond}, testUrl)
+	questions := make([]lcQuestion, 8)
+	for idx := range questions {
+		questions[idx] = lcQuestion{FrontendId: strconv.Itoa(idx), TitleSlug: "question-" + strconv.Itoa(idx)}
+	}
+	var (
+		mu                    sync.Mutex
+		inFlight, maxInFlight int
+	)
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			json.NewEncoder(w).Encode(RequestBody[lcUserProgressQuestionListData]{
+				Data: lcUserProgressQuestionListData{&lcUserProgressQuestionList{TotalNum: len(questions), Questions: questions}},
+			})
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			// Earlier questions respond slower so they finish last
+			slug := requestVariables[lcSubmissionListVariables](t, reqBody).QuestionSlug
+			idx, _ := strconv.Atoi(slug[len("question-"):])
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+			time.Sleep(time.Duration(len(questions)-idx) * 5 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			json.NewEncoder(w).Encode(RequestBody[lcSubmissionListData]{Data: lcSubmissionListData{&lcSubmissionList{
+				LCSubmissions: []lcSumbissionOverview{{Id: strconv.Itoa(idx), Lang: "golang"}},
+			}}})
+		}
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.Write(submissionDetailsResponse)
+		}
+	}
+
+	// When
+	res, err := concurrentLc.FetchSubmissions(context.Background(), time.Time{})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Len(t, res, len(questions))
+	for idx, submission := range res {
+		assert.Equal(t, strconv.Itoa(idx), submission.Id)
+		assert.Equal(t, strconv.Itoa(idx), submission.SubmissionId)
+	}
+	assert.Greater(t, maxInFlight, 1)
+}
+
+func TestFetchSubmissionsShouldReturnTheOtherQuestionsAndPartialFetchErrorWhenAQuestionFails(t *testing.T) {
+	// Given
+	questions := []lcQuestion{{FrontendId: "1", Title: "Two Sum", TitleSlug: "two-sum"}, {FrontendId: "2", Title: "Add Two Numbers", TitleSlug: "add-two-numbers"}}
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			json.NewEncoder(w).Encode(RequestBody[lcUserProgressQuestionListData]{
+				Data: lcUserProgressQuestionListData{&lcUserProgressQuestionList{TotalNum: len(questions), Questions: questions}},
+			})
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			var submissions []lcSumbissionOverview // No accepted submissions for add-two-numbers, so it fails
+			if requestVariables[lcSubmissionListVariables](t, reqBody).QuestionSlug == "two-sum" {
+				submissions = []lcSumbissionOverview{{Id: "100", Lang: "golang"}}
+			}
+			json.NewEncoder(w).Encode(RequestBody[lcSubmissionListData]{Data: lcSubmissionListData{&lcSubmissionList{LCSubmissions: submissions}}})
+		}
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.Write(submissionDetailsResponse)
+		}
+	}
+
+	// When
+	res, err := lc.FetchSubmissions(context.Background(), time.Time{})
+
+	// Then
+	assert.ErrorIs(t, err, ErrPartialFetch)
+	assert.ErrorContains(t, err, "1 of 2 questions failed")
+	assert.ErrorContains(t, err, "Add Two Numbers")
+	assert.Len(t, res, 1)
+	assert.Equal(t, "100", res[0].SubmissionId)
+}
+
 func TestFetchSubmissionsShouldReturnErrorWhenFetchQuestionsFails(t *testing.T) {
 	// Given
 	currentHandler = func(w http.ResponseWriter, reqBody string) {
@@ -92,7 +384,7 @@ func TestFetchSubmissionsShouldReturnErrorWhenFetchQuestionsFails(t *testing.T)
 	}
 
 	// When
-	_, err := lc.FetchSubmissions()
+	_, err := lc.FetchSubmissions(context.Background(), time.Time{})
 
 	// Then
 	assert.Error(t, err)
@@ -113,7 +405,7 @@ func TestFetchSubmissionsShouldReturnErrorWhenFetchSubmissionOverviewFails(t *te
 	}
 
 	// When
-	_, err := lc.FetchSubmissions()
+	_, err := lc.FetchSubmissions(context.Background(), time.Time{})
 
 	// Then
 	assert.Error(t, err)
@@ -140,7 +432,7 @@ func TestFetchSubmissionsShouldReturnErrorWhenFetchSubmissionCodeFails(t *testin
 	}
 
 	// When
-	_, err := lc.FetchSubmissions()
+	_, err := lc.FetchSubmissions(context.Background(), time.Time{})
 
 	// Then
 	assert.Error(t, err)
@@ -162,7 +454,7 @@ func TestFetchSubmissionsShouldRetryAndFailWithEmptySubmissionDataDetails(t *tes
 	}
 
 	// When
-	code, err := lc.fetchSubmissionCode("123", 0)
+	code, err := lc.fetchSubmissionCode(context.Background(), "123")
 
 	// Then
 	assert.Error(t, err)
@@ -191,7 +483,7 @@ func TestFetchSubmissionsShouldRetryAndSucceedWithValidCode(t *testing.T) {
 	}
 
 	// When
-	code, err := lc.fetchSubmissionCode("123", 0)
+	code, err := lc.fetchSubmissionCode(context.Background(), "123")
 
 	// Then
 	assert.NoError(t, err)
@@ -199,6 +491,250 @@ func TestFetchSubmissionsShouldRetryAndSucceedWithValidCode(t *testing.T) {
 	assert.Equal(t, 4, attemptCount)
 }
 
+func TestFetchSubmissionCodeShouldSlowDownAndRetryWhenRateLimited(t *testing.T) {
+	// Given
+	rateLimitedLc := NewLeetCode(config.Config{LcCookie: "COOKIE", RateLimitQuota: 100, RateLimitWindow: 100 * time.Millisecond, RetryBaseDelay: time.Millisecond}, testUrl)
+	attemptCount := 0
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "submissionDetails") {
+			attemptCount++
+			if attemptCount == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write(submissionDetailsResponse)
+		}
+	}
+	start := time.Now()
+
+	// When
+	code, err := rateLimitedLc.fetchSubmissionCode(context.Background(), "123")
+
+	// Then
+	assert.NoError(t, err)
+	assert.NotEmpty(t, code)
+	assert.Equal(t, 2, attemptCount)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond) // Waited for the rate limit window to clear
+	assert.Equal(t, 2*time.Millisecond, rateLimitedLc.limiter.interval())
+}
+
+func TestFetchSubmissionsShouldRateLimitTheListRequestsWithTheCodeRequests(t *testing.T) {
+	// Given
+	rateLimitedLc := NewLeetCode(config.Config{LcCookie: "COOKIE", Concurrency: 2, RateLimitQuota: 10, RateLimitWindow: 200 * time.Millisecond}, testUrl)
+	questions := []lcQuestion{{FrontendId: "1", TitleSlug: "two-sum"}, {FrontendId: "2", TitleSlug: "add-two-numbers"}}
+	var listRequests atomic.Int32
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			json.NewEncoder(w).Encode(RequestBody[lcUserProgressQuestionListData]{
+				Data: lcUserProgressQuestionListData{&lcUserProgressQuestionList{TotalNum: len(questions), Questions: questions}},
+			})
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			listRequests.Add(1)
+			w.Write(questionSubmissionListResponse)
+		}
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.Write(submissionDetailsResponse)
+		}
+	}
+	start := time.Now()
+
+	// When
+	res, err := rateLimitedLc.FetchSubmissions(context.Background(), time.Time{})
+
+	// Then
+	require.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, int32(2), listRequests.Load())
+	// A request every 20ms, the question list, both submission lists and both codes waited for their turn
+	assert.GreaterOrEqual(t, time.Since(start), 4*20*time.Millisecond)
+}
+
+func TestFetchQuestionsShouldSlowDownWhenLeetcodeCNRateLimitsAListRequest(t *testing.T) {
+	// Given
+	rateLimitedLc := NewLeetCode(config.Config{LcCookie: "COOKIE", RateLimitQuota: 100, RateLimitWindow: 100 * time.Millisecond, RetryBaseDelay: time.Millisecond}, testUrl)
+	attemptCount := 0
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			attemptCount++
+			if attemptCount == 1 {
+				w.Write([]byte(`{"errors": [{"message": "\u8d85\u51fa\u8bbf\u95ee\u9650\u5236"}]}`))
+				return
+			}
+			w.Write(userProgressQuestionListResponse)
+		}
+	}
+
+	// When
+	questions, err := rateLimitedLc.fetchQuestions(context.Background())
+
+	// Then
+	require.NoError(t, err)
+	assert.NotEmpty(t, questions)
+	assert.Equal(t, 2, attemptCount)
+	assert.Equal(t, 2*time.Millisecond, rateLimitedLc.limiter.interval())
+}
+
+func TestFetchSubmissionCodeShouldRetryWhenRequestTimesOut(t *testing.T) {
+	// Given
+	// The timed out request is still served after the client gave up, so it gets a server of its own
+	// that is closed before the next test replaces currentHandler
+	var attemptCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body) // The server only notices the client going away once the body is read
+		if attemptCount.Add(1) == 1 {
+			<-r.Context().Done() // Hangs until the client gives up on the request
+			return
+		}
+		w.Write(submissionDetailsResponse)
+	}))
+	defer server.Close()
+	timeoutLc := NewLeetCode(config.Config{LcCookie: "COOKIE", RequestTimeout: 50 * time.Millisecond, RetryBaseDelay: time.Millisecond, RateLimitWindow: time.Millisecond}, server.URL)
+
+	// When
+	code, err := timeoutLc.fetchSubmissionCode(context.Background(), "123")
+
+	// Then
+	assert.NoError(t, err)
+	assert.NotEmpty(t, code)
+	assert.Equal(t, int32(2), attemptCount.Load())
+}
+
+func TestFetchSubmissionsShouldStopWhenContextIsCancelled(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancel(context.Background())
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			w.Write(userProgressQuestionListResponse)
+		}
+		if strings.Contains(reqBody, "submissionList") {
+			cancel() // Interrupted while fetching the submissions
+			w.Write(questionSubmissionListResponse)
+		}
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.Write(submissionDetailsResponse)
+		}
+	}
+
+	// When
+	res, err := lc.FetchSubmissions(ctx, time.Time{})
+
+	// Then
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, res)
+}
+
+func TestFetchSubmissionCodeShouldReturnGraphqlErrors(t *testing.T) {
+	// Given
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.Write([]byte(`{"data": {"submissionDetails": null}, "errors": [{"message": "Submission not found"}]}`))
+		}
+	}
+
+	// When
+	code, err := lc.fetchSubmissionCode(context.Background(), "123")
+
+	// Then
+	assert.ErrorContains(t, err, "Submission not found")
+	assert.Empty(t, code)
+}
+
+func TestFetchSubmissionCodeShouldNotRetryWhenUnauthorized(t *testing.T) {
+	// Given
+	attemptCount := 0
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "submissionDetails") {
+			attemptCount++
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<html>Forbidden</html>"))
+		}
+	}
+
+	// When
+	code, err := lc.fetchSubmissionCode(context.Background(), "123")
+
+	// Then
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	assert.Empty(t, code)
+	assert.Equal(t, 1, attemptCount)
+}
+
+func TestFetchSubmissionCodeShouldRedactTheCookieFromErrorResponses(t *testing.T) {
+	// Given
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "submissionDetails") {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<html>Forbidden, cookie: LEETCODE_SESSION=COOKIE</html>"))
+		}
+	}
+
+	// When
+	_, err := lc.fetchSubmissionCode(context.Background(), "123")
+
+	// Then
+	assert.ErrorContains(t, err, "LEETCODE_SESSION=[REDACTED]")
+	assert.NotContains(t, err.Error(), "=COOKIE")
+}
+
+func TestFetchSubmissionCodeShouldNotRetryWhenBlockedByCloudflare(t *testing.T) {
+	// Given
+	attemptCount := 0
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "submissionDetails") {
+			attemptCount++
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<html><title>Just a moment...</title><script src=\"/cdn-cgi/challenge-platform/h/g/orchestrate/chl_page/v1\"></script></html>"))
+		}
+	}
+
+	// When
+	code, err := lc.fetchSubmissionCode(context.Background(), "123")
+
+	// Then
+	assert.ErrorIs(t, err, ErrCloudflareChallenge)
+	assert.NotErrorIs(t, err, ErrSessionExpired)
+	assert.Empty(t, code)
+	assert.Equal(t, 1, attemptCount)
+}
+
+func TestFetchSubmissionCodeShouldReturnRateLimitedErrorWhenRetriesAreExhausted(t *testing.T) {
+	// Given
+	attemptCount := 0
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "submissionDetails") {
+			attemptCount++
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}
+
+	// When
+	code, err := lc.fetchSubmissionCode(context.Background(), "123")
+
+	// Then
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Empty(t, code)
+	assert.Equal(t, maxRetry+1, attemptCount)
+}
+
+func TestFetchSubmissionsShouldReturnUnexpectedResponseErrorWhenSchemaChanges(t *testing.T) {
+	// Given
+	attemptCount := 0
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userProgressQuestionList") {
+			attemptCount++
+			w.Write([]byte(`{"data": {"userProgressQuestionListV2": {"totalNum": 1, "questions": []}}}`))
+		}
+	}
+
+	// When
+	_, err := lc.FetchSubmissions(context.Background(), time.Time{})
+
+	// Then
+	assert.ErrorIs(t, err, ErrUnexpectedResponse)
+	assert.Equal(t, 1, attemptCount)
+}
+
 func TestFetchSubmissionsShouldRetryAndFailWithEmptyCode(t *testing.T) {
 	// Given
 	attemptCount := 0
@@ -217,10 +753,25 @@ func TestFetchSubmissionsShouldRetryAndFailWithEmptyCode(t *testing.T) {
 	}
 
 	// When
-	code, err := lc.fetchSubmissionCode("123", 0)
+	code, err := lc.fetchSubmissionCode(context.Background(), "123")
 
 	// Then
 	assert.Error(t, err)
 	assert.Empty(t, code)
 	assert.Equal(t, maxRetry+1, attemptCount)
 }
+
+// Decodes the variables of the GraphQL request in reqBody into T
+func requestVariables[T any](t *testing.T, reqBody string) T {
+	var req struct {
+		Variables T `json:"variables"`
+	}
+	if err := json.Unmarshal([]byte(reqBody), &req); err != nil {
+		t.Errorf("Couldn't decode the request body %s: %v", reqBody, err)
+	}
+	return req.Variables
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
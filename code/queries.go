@@ -0,0 +1,140 @@
+package code
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// leetcodeQueries are the GraphQL operations glsync sends to LeetCode
+type leetcodeQueries struct {
+	submissionDetails        graphqlOperation
+	submissionList           graphqlOperation
+	submissionListCN         graphqlOperation
+	submissionDetailCN       graphqlOperation
+	userProgressQuestionList graphqlOperation
+	userStatus               graphqlOperation
+}
+
+// Loads the queries in dir to replace the embedded queries with the same file name,
+// so a LeetCode schema change can be worked around without waiting for a new release.
+//
+// A query that is missing from dir, can't be read or doesn't parse is left as the embedded one.
+func loadQueries(dir string) leetcodeQueries {
+	queries := embeddedQueries
+	if dir == "" {
+		return queries
+	}
+	if _, err := os.Stat(dir); err != nil {
+		log.Printf("Warning: couldn't open the queries folder %v, using the embedded queries instead: %v\n", dir, err)
+		return queries
+	}
+	for _, query := range []struct {
+		file string
+		op   *graphqlOperation
+	}{
+		{"submission-details.graphql", &queries.submissionDetails},
+		{"submission-list.graphql", &queries.submissionList},
+		{"submission-list-cn.graphql", &queries.submissionListCN},
+		{"submission-detail-cn.graphql", &queries.submissionDetailCN},
+		{"user-progress-question-list.graphql", &queries.userProgressQuestionList},
+		{"user-status.graphql", &queries.userStatus},
+	} {
+		*query.op = loadQuery(filepath.Join(dir, query.file), *query.op)
+	}
+	return queries
+}
+
+// Returns the operation in the file at path, or embedded if it's missing or invalid
+func loadQuery(path string, embedded graphqlOperation) graphqlOperation {
+	query, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return embedded
+	}
+	if err != nil {
+		log.Printf("Warning: couldn't read %v, using the embedded query instead: %v\n", path, err)
+		return embedded
+	}
+	if err := validateQuery(string(query), embedded.name); err != nil {
+		log.Printf("Warning: %v is not a valid query, using the embedded query instead: %v\n", path, err)
+		return embedded
+	}
+	log.Printf("Using the %v query in %v instead of the embedded one\n", embedded.name, path)
+	return graphqlOperation{embedded.name, string(query)}
+}
+
+// Checks that query is well-formed enough to be sent to LeetCode and that it defines
+// the operation called operationName, which is what glsync asks LeetCode to run.
+//
+// It isn't a full GraphQL parser, it checks the brackets are balanced outside of
+// strings and comments, which catches truncated or badly edited files.
+func validateQuery(query, operationName string) error {
+	var (
+		stripped strings.Builder // The query without strings and comments
+		brackets []rune
+	)
+	closing := map[rune]rune{')': '(', '}': '{', ']': '['}
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '#': // Comment until the end of the line
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case '"':
+			end, err := stringEnd(runes, i)
+			if err != nil {
+				return err
+			}
+			i = end
+			stripped.WriteString(`""`)
+		case '(', '{', '[':
+			brackets = append(brackets, r)
+			stripped.WriteRune(r)
+		case ')', '}', ']':
+			if len(brackets) == 0 || brackets[len(brackets)-1] != closing[r] {
+				return fmt.Errorf("unexpected %q", r)
+			}
+			brackets = brackets[:len(brackets)-1]
+			stripped.WriteRune(r)
+		default:
+			stripped.WriteRune(r)
+		}
+	}
+	if len(brackets) > 0 {
+		return fmt.Errorf("unclosed %q", brackets[len(brackets)-1])
+	}
+	operation := regexp.MustCompile(`\b(query|mutation)\s+` + regexp.QuoteMeta(operationName) + `\b`)
+	if !operation.MatchString(stripped.String()) {
+		return fmt.Errorf("it doesn't define the %v operation", operationName)
+	}
+	return nil
+}
+
+// Returns the index of the quote closing the string or block string starting at start
+func stringEnd(runes []rune, start int) (int, error) {
+	if strings.HasPrefix(string(runes[start:]), `"""`) {
+		for i := start + 3; i+2 < len(runes); i++ {
+			if string(runes[i:i+3]) == `"""` {
+				return i + 2, nil
+			}
+		}
+		return 0, errors.New("unterminated block string")
+	}
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '"':
+			return i, nil
+		case '\n':
+			return 0, errors.New("unterminated string")
+		}
+	}
+	return 0, errors.New("unterminated string")
+}
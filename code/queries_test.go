@@ -0,0 +1,77 @@
+package code
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadQueriesShouldReplaceEmbeddedQueriesWithTheOnesInDir(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	newQuery := "query submissionList($questionSlug: String!) {\n  questionSubmissionListV2(questionSlug: $questionSlug) {\n    lastKey\n  }\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "submission-list.graphql"), []byte(newQuery), 0o644))
+
+	// When
+	queries := loadQueries(dir)
+
+	// Then
+	assert.Equal(t, graphqlOperation{"submissionList", newQuery}, queries.submissionList)
+	assert.Equal(t, embeddedQueries.submissionDetails, queries.submissionDetails) // Missing from dir
+}
+
+func TestLoadQueriesShouldFallBackToEmbeddedQueriesWhenInvalid(t *testing.T) {
+	for name, query := range map[string]string{
+		"unbalanced brackets":  "query submissionDetails($submissionId: Int!) {\n  submissionDetails(submissionId: $submissionId) {\n    code\n}\n",
+		"wrong operation name": "query submissionDetailsV2($submissionId: Int!) {\n  submissionDetails(submissionId: $submissionId) {\n    code\n  }\n}\n",
+		"unterminated string":  "query submissionDetails {\n  submissionDetails(submissionId: \"1) {\n    code\n  }\n}\n",
+		"empty":                "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "submission-details.graphql"), []byte(query), 0o644))
+
+			// When
+			queries := loadQueries(dir)
+
+			// Then
+			assert.Equal(t, embeddedQueries.submissionDetails, queries.submissionDetails)
+		})
+	}
+}
+
+func TestLoadQueriesShouldUseEmbeddedQueriesWhenDirDoesNotExist(t *testing.T) {
+	// When
+	queries := loadQueries(filepath.Join(t.TempDir(), "missing"))
+
+	// Then
+	assert.Equal(t, embeddedQueries, queries)
+}
+
+func TestEmbeddedQueriesShouldBeValid(t *testing.T) {
+	for _, op := range []graphqlOperation{
+		embeddedQueries.submissionDetails,
+		embeddedQueries.submissionList,
+		embeddedQueries.submissionListCN,
+		embeddedQueries.submissionDetailCN,
+		embeddedQueries.userProgressQuestionList,
+		embeddedQueries.userStatus,
+	} {
+		assert.NoError(t, validateQuery(op.query, op.name), op.name)
+	}
+}
+
+func TestValidateQueryShouldIgnoreBracketsInStringsAndComments(t *testing.T) {
+	// Given
+	query := "# A comment with an unbalanced {\nquery userStatus {\n  user(name: \"}\", bio: \"\"\"\n)\n\"\"\") {\n    id\n  }\n}\n"
+
+	// When
+	err := validateQuery(query, "userStatus")
+
+	// Then
+	assert.NoError(t, err)
+}
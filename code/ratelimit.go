@@ -0,0 +1,135 @@
+package code
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// Consecutive successful requests before a slowed down limiter speeds back up
+	successesToSpeedUp = 20
+	// The limiter never slows down below 1/maxSlowdown of the configured rate
+	maxSlowdown = 16
+)
+
+// rateLimit is a quota of requests allowed within a window
+type rateLimit struct {
+	quota  int
+	window time.Duration
+}
+
+// Default rate limits of the GraphQL requests per site, keyed by cookie domain.
+// They can be overridden with cfg.RateLimitQuota and cfg.RateLimitWindow.
+var defaultRateLimits = map[string]rateLimit{
+	// Measured with cmd/ratelimit-probe: 10-minute sliding window, quota of 60 requests (1 req/10s).
+	// Total run time: ~560 * 2 requests (submission list and code) * 10s = ~190 minutes for 560 questions.
+	".leetcode.cn": {quota: 60, window: 10 * time.Minute},
+	// leetcode.com didn't rate limit glsync so far, this only keeps concurrent workers from hammering it
+	".leetcode.com": {quota: 300, window: time.Minute},
+}
+
+// rateLimiter is an adaptive token bucket shared by all the workers fetching submissions.
+//
+// Tokens are refilled at quota/window per second and the bucket holds a single token,
+// as LeetCode counts requests in a sliding window, a burst followed by the steady rate
+// would go over the quota. When LeetCode reports that the rate limit was hit, the refill
+// rate is halved and no tokens are handed out for a whole window, which is how long a
+// sliding window takes to clear. After successesToSpeedUp consecutive successful requests
+// the rate is doubled again until it's back to the configured one.
+type rateLimiter struct {
+	mu          sync.Mutex
+	maxRate     float64   // Configured tokens per second
+	rate        float64   // Current tokens per second, lowered while LeetCode is rate limiting
+	tokens      float64   // Negative when requests reserved tokens that weren't refilled yet
+	last        time.Time // When tokens were last refilled, in the future while paused
+	window      time.Duration
+	pausedUntil time.Time
+	successes   int
+}
+
+// Creates a rate limiter allowing quota requests per window, a non-positive quota or window means no limit
+func newRateLimiter(limit rateLimit) *rateLimiter {
+	if limit.quota <= 0 || limit.window <= 0 {
+		return &rateLimiter{}
+	}
+	rate := float64(limit.quota) / limit.window.Seconds()
+	return &rateLimiter{maxRate: rate, rate: rate, tokens: 1, last: time.Now(), window: limit.window}
+}
+
+// Wait blocks until the caller is allowed to send its request, or returns ctx's cause if ctx is done first
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l.maxRate == 0 {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		wait := l.reserve(time.Now())
+		l.mu.Unlock()
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+
+		l.mu.Lock()
+		paused := time.Now().Before(l.pausedUntil)
+		l.mu.Unlock()
+		if !paused { // The limiter may have been paused while sleeping, reserve another token if so
+			return nil
+		}
+	}
+}
+
+// Takes a token from the bucket and returns how long to wait until it's refilled
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	if now.After(l.last) {
+		l.tokens = min(1, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+	}
+	l.tokens--
+	wait := l.last.Sub(now)
+	if l.tokens < 0 {
+		wait += time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	return wait
+}
+
+// OnRateLimited slows the limiter down after LeetCode reported that its rate limit was hit
+func (l *rateLimiter) OnRateLimited() {
+	if l.maxRate == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = max(l.rate/2, l.maxRate/maxSlowdown)
+	l.successes = 0
+	l.pausedUntil = time.Now().Add(l.window)
+	l.tokens = 0
+	l.last = l.pausedUntil
+}
+
+// OnSuccess speeds the limiter back up after sustained successful requests
+func (l *rateLimiter) OnSuccess() {
+	if l.maxRate == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate >= l.maxRate {
+		return
+	}
+	l.successes++
+	if l.successes >= successesToSpeedUp {
+		l.rate = min(l.rate*2, l.maxRate)
+		l.successes = 0
+	}
+}
+
+// Returns the time between requests at the current rate
+func (l *rateLimiter) interval() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate == 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / l.rate)
+}
@@ -0,0 +1,107 @@
+package code
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterShouldSpaceOutConcurrentRequests(t *testing.T) {
+	// Given
+	limiter := newRateLimiter(rateLimit{quota: 20, window: time.Second}) // A request every 50ms
+	start := time.Now()
+
+	// When
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter.Wait(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	// Then the first request goes right away and the other two wait for their turn
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestRateLimiterWaitShouldStopWhenContextIsCancelled(t *testing.T) {
+	// Given
+	limiter := newRateLimiter(rateLimit{quota: 1, window: time.Hour})
+	limiter.Wait(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+
+	// When
+	err := limiter.Wait(ctx)
+
+	// Then it doesn't wait an hour for the next token
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRateLimiterWithoutQuotaShouldNotWait(t *testing.T) {
+	// Given
+	limiter := newRateLimiter(rateLimit{})
+	start := time.Now()
+
+	// When
+	for range 100 {
+		limiter.Wait(context.Background())
+		limiter.OnRateLimited()
+	}
+
+	// Then
+	assert.Less(t, time.Since(start), 10*time.Millisecond)
+}
+
+func TestRateLimiterShouldPauseAndSlowDownWhenRateLimited(t *testing.T) {
+	// Given
+	limiter := newRateLimiter(rateLimit{quota: 10, window: 100 * time.Millisecond}) // A request every 10ms
+	limiter.Wait(context.Background())
+
+	// When
+	limiter.OnRateLimited()
+	start := time.Now()
+	limiter.Wait(context.Background())
+
+	// Then it waits for the window to clear and the rate is halved
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, 20*time.Millisecond, limiter.interval())
+}
+
+func TestRateLimiterShouldNotSlowDownBelowMaxSlowdown(t *testing.T) {
+	// Given
+	limiter := newRateLimiter(rateLimit{quota: 10, window: time.Millisecond})
+
+	// When
+	for range 10 {
+		limiter.OnRateLimited()
+	}
+
+	// Then
+	assert.Equal(t, maxSlowdown*100*time.Microsecond, limiter.interval())
+}
+
+func TestRateLimiterShouldSpeedBackUpAfterSustainedSuccess(t *testing.T) {
+	// Given
+	limiter := newRateLimiter(rateLimit{quota: 10, window: time.Millisecond}) // A request every 100µs
+	limiter.OnRateLimited()
+	limiter.OnRateLimited()
+
+	// When
+	for range successesToSpeedUp - 1 {
+		limiter.OnSuccess()
+	}
+	intervalBeforeSpeedUp := limiter.interval()
+	limiter.OnSuccess()
+
+	// Then
+	assert.Equal(t, 400*time.Microsecond, intervalBeforeSpeedUp)
+	assert.Equal(t, 200*time.Microsecond, limiter.interval())
+}
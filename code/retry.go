@@ -0,0 +1,159 @@
+package code
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultMaxRetry       = maxRetry
+	defaultRetryBaseDelay = backoffTime
+	defaultRetryMaxDelay  = 30 * time.Second
+	// Fraction of each delay that is randomized, so concurrent workers don't retry in lockstep
+	retryJitter = 0.2
+)
+
+var (
+	errNullResponse = errors.New("null response")
+	errEmptyCode    = errors.New("empty code")
+)
+
+// RetryPolicy retries failed LeetCode requests with exponential backoff and jitter
+//
+// The delay before retry n is BaseDelay * 2^n capped at MaxDelay, randomized by ±Jitter of itself.
+// Terminal errors, such as an HTTP 401/403 or a response that can't be parsed, are returned right away
+// as retrying them would only fail the same way.
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt
+	BaseDelay  time.Duration // Delay before the first retry
+	MaxDelay   time.Duration // Upper bound of the delay between retries
+	Jitter     float64       // Fraction of the delay that is randomized
+}
+
+// Returns the policy configured by cfg.MaxRetries, cfg.RetryBaseDelay and cfg.RetryMaxDelay,
+// using the defaults for the ones that aren't set
+func newRetryPolicy(maxRetries int, baseDelay, maxDelay time.Duration) RetryPolicy {
+	policy := RetryPolicy{defaultMaxRetry, defaultRetryBaseDelay, defaultRetryMaxDelay, retryJitter}
+	if maxRetries > 0 {
+		policy.MaxRetries = maxRetries
+	}
+	if baseDelay > 0 {
+		policy.BaseDelay = baseDelay
+	}
+	if maxDelay > 0 {
+		policy.MaxDelay = maxDelay
+	}
+	policy.MaxDelay = max(policy.MaxDelay, policy.BaseDelay)
+	return policy
+}
+
+// Do calls fn until it succeeds, fails with a terminal error, MaxRetries retries were made or ctx is done
+//
+// description is used in logs and errors, e.g. "fetching code of submission 123"
+func (p RetryPolicy) Do(ctx context.Context, description string, fn func() error) error {
+	for retry := 0; ; retry++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil { // The failure is likely caused by the cancellation, don't retry it
+			return fmt.Errorf("stopped %s: %w", description, context.Cause(ctx))
+		}
+		if !isRetryable(err) {
+			return err
+		}
+		if retry >= p.MaxRetries {
+			return fmt.Errorf("max retries reached while %s: %w", description, err)
+		}
+		delay := p.delay(retry)
+		log.Printf("Error while %s: %v, retry %d/%d after %v\n", description, err, retry+1, p.MaxRetries, delay)
+		if err := sleep(ctx, delay); err != nil {
+			return fmt.Errorf("stopped %s: %w", description, err)
+		}
+	}
+}
+
+// sleep pauses for d or until ctx is done, returning ctx's cause in the latter case
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// Returns the delay before the given retry, starting from 0
+func (p RetryPolicy) delay(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	if p.Jitter > 0 {
+		jitter := (rand.Float64()*2 - 1) * p.Jitter * float64(delay)
+		delay += time.Duration(jitter)
+	}
+	return delay
+}
+
+// terminalError marks an error that shouldn't be retried
+type terminalError struct {
+	err error
+}
+
+func (e terminalError) Error() string {
+	return e.err.Error()
+}
+
+func (e terminalError) Unwrap() error {
+	return e.err
+}
+
+func terminal(err error) error {
+	return terminalError{err}
+}
+
+// httpStatusError is returned by queryLeetcode when LeetCode answers with an error page instead of JSON
+// or rejects the request's credentials
+type httpStatusError struct {
+	StatusCode int
+	Url        string
+	Preview    string // The beginning of the response body
+	Kind       error  // ErrSessionExpired or ErrCloudflareChallenge if the error was recognized, nil otherwise
+}
+
+func (e *httpStatusError) Error() string {
+	if e.Kind != nil {
+		return fmt.Sprintf("%v: unexpected response (HTTP %d) from %s: %s", e.Kind, e.StatusCode, e.Url, e.Preview)
+	}
+	return fmt.Sprintf("unexpected non-JSON response (HTTP %d) from %s: %s", e.StatusCode, e.Url, e.Preview)
+}
+
+func (e *httpStatusError) Unwrap() error {
+	return e.Kind
+}
+
+// Network errors, rate limits, server errors and null or empty responses are retryable.
+// Authentication failures (HTTP 401/403), Cloudflare challenges and terminal errors aren't.
+func isRetryable(err error) bool {
+	var terminalErr terminalError
+	if errors.As(err, &terminalErr) {
+		return false
+	}
+	if errors.Is(err, ErrSessionExpired) || errors.Is(err, ErrCloudflareChallenge) {
+		return false
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode != http.StatusUnauthorized && statusErr.StatusCode != http.StatusForbidden
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package code
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyShouldRetryUntilSuccess(t *testing.T) {
+	// Given
+	policy := RetryPolicy{MaxRetries: 5, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	attemptCount := 0
+
+	// When
+	err := policy.Do(context.Background(), "testing", func() error {
+		attemptCount++
+		if attemptCount < 3 {
+			return errNullResponse
+		}
+		return nil
+	})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attemptCount)
+}
+
+func TestRetryPolicyShouldGiveUpAfterMaxRetries(t *testing.T) {
+	// Given
+	policy := RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	attemptCount := 0
+
+	// When
+	err := policy.Do(context.Background(), "testing", func() error {
+		attemptCount++
+		return errEmptyCode
+	})
+
+	// Then
+	assert.ErrorIs(t, err, errEmptyCode)
+	assert.Equal(t, 3, attemptCount)
+}
+
+func TestRetryPolicyShouldStopRetryingWhenContextIsCancelled(t *testing.T) {
+	// Given
+	policy := RetryPolicy{MaxRetries: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	attemptCount := 0
+
+	// When
+	err := policy.Do(ctx, "testing", func() error {
+		attemptCount++
+		time.AfterFunc(10*time.Millisecond, cancel) // Cancelled while waiting to retry
+		return errNullResponse
+	})
+
+	// Then
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, attemptCount)
+}
+
+func TestRetryPolicyShouldNotRetryTerminalErrors(t *testing.T) {
+	for name, err := range map[string]error{
+		"terminal":     terminal(errors.New("unparsable response")),
+		"unauthorized": &httpStatusError{StatusCode: http.StatusUnauthorized},
+		"forbidden":    &httpStatusError{StatusCode: http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			policy := RetryPolicy{MaxRetries: 5, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+			attemptCount := 0
+
+			// When
+			actualErr := policy.Do(context.Background(), "testing", func() error {
+				attemptCount++
+				return err
+			})
+
+			// Then
+			assert.ErrorIs(t, actualErr, err)
+			assert.Equal(t, 1, attemptCount)
+		})
+	}
+}
+
+func TestRetryPolicyDelayShouldGrowExponentiallyUpToMaxDelay(t *testing.T) {
+	// Given
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	// Then
+	assert.Equal(t, time.Second, policy.delay(0))
+	assert.Equal(t, 2*time.Second, policy.delay(1))
+	assert.Equal(t, 8*time.Second, policy.delay(3))
+	assert.Equal(t, 10*time.Second, policy.delay(4))
+	assert.Equal(t, 10*time.Second, policy.delay(100))
+}
+
+func TestRetryPolicyDelayShouldStayWithinJitter(t *testing.T) {
+	// Given
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Second, Jitter: 0.2}
+
+	for range 100 {
+		// When
+		delay := policy.delay(0)
+
+		// Then
+		assert.GreaterOrEqual(t, delay, 800*time.Millisecond)
+		assert.LessOrEqual(t, delay, 1200*time.Millisecond)
+	}
+}
@@ -0,0 +1,94 @@
+package code
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// A session expiring sooner than this is reported so the cookie can be refreshed before the next sync fails
+const sessionExpiryWarning = 7 * 24 * time.Hour
+
+// Session is the LeetCode account the cookie is signed in to
+type Session struct {
+	Username  string
+	Site      string    // e.g. "leetcode.com" or "leetcode.cn"
+	ExpiresAt time.Time // Zero if the cookie doesn't say when it expires
+}
+
+// CheckSession confirms the cookie is signed in to LeetCode before anything else is done,
+// so an expired session fails right away with ErrSessionExpired instead of deep into the sync.
+//
+// Warns if the cookie expires within sessionExpiryWarning.
+func (lc leetcode) CheckSession(ctx context.Context) (Session, error) {
+	session := Session{Site: strings.TrimPrefix(lc.cookieDomain, "."), ExpiresAt: cookieExpiry(lc.cfg.LcCookie)}
+	var status *lcUserStatus
+	err := lc.retry.Do(ctx, "checking the leetcode session", func() error {
+		bodyBytes, err := lc.queryLeetcode(ctx, lc.queries.userStatus, struct{}{})
+		if err != nil {
+			return err
+		}
+		data, err := decodeGraphqlResponse[lcUserStatusData](bodyBytes)
+		if err != nil {
+			return err
+		}
+		if data.UserStatus == nil {
+			return terminal(fmt.Errorf("user status response from leetcode has no userStatus: %w", ErrUnexpectedResponse))
+		}
+		status = data.UserStatus
+		return nil
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	if !status.IsSignedIn {
+		return Session{}, fmt.Errorf("%v doesn't recognize the cookie as a signed in session: %w", session.Site, ErrSessionExpired)
+	}
+	session.Username = status.Username
+
+	if !session.ExpiresAt.IsZero() && time.Until(session.ExpiresAt) < sessionExpiryWarning {
+		log.Printf("Warning: your LeetCode session expires at %v, log in again and refresh the cookie soon\n",
+			session.ExpiresAt.Format(time.RFC3339))
+	}
+	return session, nil
+}
+
+// Returns when the LEETCODE_SESSION JWT expires using its exp claim,
+// or LeetCode's own expired_time_ claim if exp is missing. Returns zero if neither is set.
+func cookieExpiry(cookie string) time.Time {
+	parts := strings.Split(cookie, ".")
+	if len(parts) < 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp         int64 `json:"exp"`
+		ExpiredTime int64 `json:"expired_time_"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}
+	}
+	if claims.Exp > 0 {
+		return time.Unix(claims.Exp, 0)
+	}
+	if claims.ExpiredTime > 0 {
+		return time.Unix(claims.ExpiredTime, 0)
+	}
+	return time.Time{}
+}
+
+type lcUserStatusData struct {
+	UserStatus *lcUserStatus `json:"userStatus"`
+}
+
+type lcUserStatus struct {
+	IsSignedIn bool   `json:"isSignedIn"`
+	Username   string `json:"username"`
+}
@@ -0,0 +1,77 @@
+package code
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed leetcode-testdata/leetcode-responses/user-status-response.json
+var userStatusResponse []byte
+
+func TestCheckSessionShouldReturnTheSignedInUser(t *testing.T) {
+	// Given
+	expiresAt := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	sessionLc := NewLeetCode(config.Config{LcCookie: fakeCookie(`{"exp": ` + strconv.FormatInt(expiresAt.Unix(), 10) + `}`)}, testUrl)
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userStatus") {
+			w.Write(userStatusResponse)
+		}
+	}
+
+	// When
+	session, err := sessionLc.CheckSession(context.Background())
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, Session{Username: "ahmedehab95", Site: "leetcode.com", ExpiresAt: expiresAt}, session)
+}
+
+func TestCheckSessionShouldFailWhenNotSignedIn(t *testing.T) {
+	// Given
+	attemptCount := 0
+	currentHandler = func(w http.ResponseWriter, reqBody string) {
+		if strings.Contains(reqBody, "userStatus") {
+			attemptCount++
+			w.Write([]byte(`{"data": {"userStatus": {"isSignedIn": false, "username": ""}}}`))
+		}
+	}
+
+	// When
+	_, err := lc.CheckSession(context.Background())
+
+	// Then
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	assert.Equal(t, 1, attemptCount)
+}
+
+func TestCookieExpiry(t *testing.T) {
+	expiresAt := time.Unix(1767225600, 0)
+	for name, testCase := range map[string]struct {
+		cookie   string
+		expected time.Time
+	}{
+		"exp claim":           {fakeCookie(`{"exp": 1767225600}`), expiresAt},
+		"expired_time_ claim": {fakeCookie(`{"expired_time_": 1767225600}`), expiresAt},
+		"no expiry claim":     {fakeCookie(`{"username": "ahmedehab95"}`), time.Time{}},
+		"not a JWT":           {"COOKIE", time.Time{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, cookieExpiry(testCase.cookie))
+		})
+	}
+}
+
+// Returns a JWT with the given payload, its header and signature aren't checked by glsync
+func fakeCookie(payload string) string {
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+}
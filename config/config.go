@@ -1,10 +1,28 @@
 package config
 
+import "time"
+
+// Config holds the options of a sync, the yaml keys match the names of the flags setting them
 type Config struct {
-	LcCookie    string // LeetCode's cookie that you can get from Chrome Devtools->Application tab->Cookies->LEETCODE_SESSION
-	RepoUrl     string // The repo to push the submitted code to
-	BearerToken string // A user reported that LeetCode is now expecting a bearer token, this will be passed as Authorization: Bearer header to LeetCode. Check https://github.com/ahmed-e-abdulaziz/glsync/issues/5 for more info
-	LcSite        string // Target LeetCode site: "com" for leetcode.com (default), "cn" for leetcode.cn
-	LcCsrfToken   string // CSRF token required by leetcode.cn; get it from the csrftoken cookie in your browser
-	LcCfClearance string // Cloudflare clearance cookie for leetcode.cn; get it from the cf_clearance cookie in your browser
+	LcCookie        string        `yaml:"lc-cookie"`         // LeetCode's cookie that you can get from Chrome Devtools->Application tab->Cookies->LEETCODE_SESSION
+	RepoUrl         string        `yaml:"repo-url"`          // The repo to push the submitted code to
+	BearerToken     string        `yaml:"bearer-token"`      // A user reported that LeetCode is now expecting a bearer token, this will be passed as Authorization: Bearer header to LeetCode. Check https://github.com/ahmed-e-abdulaziz/glsync/issues/5 for more info
+	CookieOnly      bool          `yaml:"cookie-only"`       // Only send the cookies to LeetCode, otherwise BearerToken, or LcCookie if it's empty, is sent as an Authorization: Bearer header too
+	LcSite          string        `yaml:"site"`              // Target LeetCode site: "com" for leetcode.com (default), "cn" for leetcode.cn
+	LcCsrfToken     string        `yaml:"lc-csrf-token"`     // CSRF token required by leetcode.cn; get it from the csrftoken cookie in your browser
+	LcCfClearance   string        `yaml:"lc-cf-clearance"`   // Cloudflare clearance cookie for leetcode.cn; get it from the cf_clearance cookie in your browser
+	AllSubmissions  bool          `yaml:"all-submissions"`   // Sync every accepted submission of a question instead of only the latest one
+	AllLanguages    bool          `yaml:"all-languages"`     // Sync the latest accepted submission in every language of a question instead of only the latest one
+	StateFile       string        `yaml:"state-file"`        // Path of the file remembering what was synced already, so later runs only sync what changed since
+	Concurrency     int           `yaml:"concurrency"`       // Number of questions to fetch submissions for in parallel
+	RateLimitQuota  int           `yaml:"rate-limit-quota"`  // Submission detail requests allowed per RateLimitWindow, 0 uses the site's default
+	RateLimitWindow time.Duration `yaml:"rate-limit-window"` // Window of RateLimitQuota, 0 uses the site's default
+	MaxRetries      int           `yaml:"max-retries"`       // Retries of a failed LeetCode request, 0 uses the default of 25
+	RetryBaseDelay  time.Duration `yaml:"retry-base-delay"`  // Delay before the first retry, doubled on each following one, 0 uses the default of 1s
+	RetryMaxDelay   time.Duration `yaml:"retry-max-delay"`   // Upper bound of the delay between retries, 0 uses the default of 30s
+	RequestTimeout  time.Duration `yaml:"request-timeout"`   // Timeout of a single LeetCode request, 0 uses the default of 30s
+	QueriesDir      string        `yaml:"queries-dir"`       // Folder of GraphQL query files replacing the embedded ones with the same name
+	CookiesFile     string        `yaml:"cookies-file"`      // Netscape cookies.txt or JSON cookies export to read LcCookie, LcCsrfToken and LcCfClearance from
+	GitClient       string        `yaml:"git-client"`        // Name of the git.GitClient implementation to commit with, see git.Clients
+	RepoPath        string        `yaml:"repo-path"`         // Existing checkout of the repo to commit into instead of cloning RepoUrl, it's left in place
 }
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sessionCookie     = "LEETCODE_SESSION"
+	csrfTokenCookie   = "csrftoken"
+	cfClearanceCookie = "cf_clearance"
+)
+
+// cookie is a browser cookie read from a cookies file
+type cookie struct {
+	Domain    string
+	Name      string
+	Value     string
+	ExpiresAt time.Time // Zero for session cookies
+}
+
+// LoadCookiesFile fills LcCookie, LcCsrfToken and LcCfClearance from the cookies of LcSite's domain
+// in the file at path, the ones that are already set are kept as they are.
+//
+// The file can be in the Netscape cookies.txt format or a JSON array of cookies as exported
+// by browser extensions such as Cookie-Editor or EditThisCookie. Expired cookies are ignored.
+func (c *Config) LoadCookiesFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("couldn't read the cookies file: %w", err)
+	}
+	var cookies []cookie
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		cookies, err = parseJsonCookies(trimmed)
+	} else {
+		cookies, err = parseNetscapeCookies(content)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't parse the cookies file %v: %w", path, err)
+	}
+
+	domain := "leetcode." + c.LcSite
+	found := map[string]string{}
+	for _, ck := range cookies {
+		if !matchesDomain(ck.Domain, domain) || (!ck.ExpiresAt.IsZero() && ck.ExpiresAt.Before(time.Now())) {
+			continue
+		}
+		found[ck.Name] = ck.Value
+	}
+	if found[sessionCookie] == "" && c.LcCookie == "" {
+		return fmt.Errorf("the cookies file %v has no unexpired %v cookie for %v", path, sessionCookie, domain)
+	}
+	if c.LcCookie == "" {
+		c.LcCookie = found[sessionCookie]
+	}
+	if c.LcCsrfToken == "" {
+		c.LcCsrfToken = found[csrfTokenCookie]
+	}
+	if c.LcCfClearance == "" {
+		c.LcCfClearance = found[cfClearanceCookie]
+	}
+	return nil
+}
+
+// Returns whether a cookie set for cookieDomain is sent to domain, e.g. ".leetcode.com" to "leetcode.com".
+// Like browsers, cookies of a parent domain are sent to its subdomains but not the other way around.
+func matchesDomain(cookieDomain, domain string) bool {
+	cookieDomain = strings.TrimPrefix(cookieDomain, ".")
+	return cookieDomain == domain || strings.HasSuffix(domain, "."+cookieDomain)
+}
+
+// Parses the Netscape cookies.txt format, each line has the tab separated fields:
+// domain, include subdomains, path, secure, expiry as unix seconds, name and value.
+// Lines starting with # are comments, except for #HttpOnly_ which marks HttpOnly cookies.
+func parseNetscapeCookies(content []byte) ([]cookie, error) {
+	var cookies []cookie
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %v has %v fields instead of 7", lineNumber, len(fields))
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %v has an invalid expiry %q", lineNumber, fields[4])
+		}
+		ck := cookie{Domain: fields[0], Name: fields[5], Value: fields[6]}
+		if expiry > 0 {
+			ck.ExpiresAt = time.Unix(expiry, 0)
+		}
+		cookies = append(cookies, ck)
+	}
+	return cookies, scanner.Err()
+}
+
+// jsonCookie is a cookie as exported by browser extensions, they follow the chrome.cookies API
+type jsonCookie struct {
+	Domain         string   `json:"domain"`
+	Name           string   `json:"name"`
+	Value          string   `json:"value"`
+	ExpirationDate *float64 `json:"expirationDate"` // Unix seconds, missing for session cookies
+	Session        bool     `json:"session"`
+}
+
+// Parses a JSON array of cookies, or an object having the array in its "cookies" field
+func parseJsonCookies(content []byte) ([]cookie, error) {
+	var jsonCookies []jsonCookie
+	if content[0] == '{' {
+		var export struct {
+			Cookies []jsonCookie `json:"cookies"`
+		}
+		if err := json.Unmarshal(content, &export); err != nil {
+			return nil, err
+		}
+		if export.Cookies == nil {
+			return nil, errors.New(`the JSON object has no "cookies" array`)
+		}
+		jsonCookies = export.Cookies
+	} else if err := json.Unmarshal(content, &jsonCookies); err != nil {
+		return nil, err
+	}
+
+	cookies := make([]cookie, 0, len(jsonCookies))
+	for _, jc := range jsonCookies {
+		ck := cookie{Domain: jc.Domain, Name: jc.Name, Value: jc.Value}
+		if jc.ExpirationDate != nil && !jc.Session {
+			ck.ExpiresAt = time.Unix(int64(*jc.ExpirationDate), 0)
+		}
+		cookies = append(cookies, ck)
+	}
+	return cookies, nil
+}
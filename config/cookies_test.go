@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var nextYear = strconv.FormatInt(time.Now().AddDate(1, 0, 0).Unix(), 10)
+
+func TestLoadCookiesFileShouldReadNetscapeCookiesOfTheSite(t *testing.T) {
+	// Given
+	path := writeCookiesFile(t, "cookies.txt", "# Netscape HTTP Cookie File\n"+
+		"#HttpOnly_.leetcode.cn\tTRUE\t/\tTRUE\t"+nextYear+"\tLEETCODE_SESSION\tCN_SESSION\n"+
+		".leetcode.cn\tTRUE\t/\tTRUE\t"+nextYear+"\tcsrftoken\tCN_CSRF\n"+
+		".leetcode.cn\tTRUE\t/\tTRUE\t0\tcf_clearance\tCN_CLEARANCE\n"+
+		"#HttpOnly_.leetcode.com\tTRUE\t/\tTRUE\t"+nextYear+"\tLEETCODE_SESSION\tCOM_SESSION\n"+
+		"\n")
+	cfg := Config{LcSite: "cn"}
+
+	// When
+	err := cfg.LoadCookiesFile(path)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "CN_SESSION", cfg.LcCookie)
+	assert.Equal(t, "CN_CSRF", cfg.LcCsrfToken)
+	assert.Equal(t, "CN_CLEARANCE", cfg.LcCfClearance)
+}
+
+func TestLoadCookiesFileShouldReadJsonExports(t *testing.T) {
+	for name, content := range map[string]string{
+		"array": `[
+			{"domain": ".leetcode.com", "name": "LEETCODE_SESSION", "value": "COM_SESSION", "expirationDate": ` + nextYear + `.5},
+			{"domain": "leetcode.com", "name": "csrftoken", "value": "COM_CSRF", "session": true},
+			{"domain": ".leetcode.cn", "name": "csrftoken", "value": "CN_CSRF"}
+		]`,
+		"object": `{"url": "https://leetcode.com", "cookies": [
+			{"domain": ".leetcode.com", "name": "LEETCODE_SESSION", "value": "COM_SESSION", "expirationDate": ` + nextYear + `},
+			{"domain": "leetcode.com", "name": "csrftoken", "value": "COM_CSRF"}
+		]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			path := writeCookiesFile(t, "cookies.json", content)
+			cfg := Config{LcSite: "com"}
+
+			// When
+			err := cfg.LoadCookiesFile(path)
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, "COM_SESSION", cfg.LcCookie)
+			assert.Equal(t, "COM_CSRF", cfg.LcCsrfToken)
+		})
+	}
+}
+
+func TestLoadCookiesFileShouldIgnoreTheCookiesOfSubdomains(t *testing.T) {
+	// Given
+	path := writeCookiesFile(t, "cookies.txt", "# Netscape HTTP Cookie File\n"+
+		"#HttpOnly_.leetcode.com\tTRUE\t/\tTRUE\t"+nextYear+"\tLEETCODE_SESSION\tCOM_SESSION\n"+
+		".leetcode.com\tTRUE\t/\tTRUE\t"+nextYear+"\tcsrftoken\tCOM_CSRF\n"+
+		"#HttpOnly_sub.leetcode.com\tFALSE\t/\tTRUE\t"+nextYear+"\tLEETCODE_SESSION\tSUB_SESSION\n"+
+		"sub.leetcode.com\tFALSE\t/\tTRUE\t"+nextYear+"\tcsrftoken\tSUB_CSRF\n")
+	cfg := Config{LcSite: "com"}
+
+	// When
+	err := cfg.LoadCookiesFile(path)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "COM_SESSION", cfg.LcCookie)
+	assert.Equal(t, "COM_CSRF", cfg.LcCsrfToken)
+}
+
+func TestLoadCookiesFileShouldKeepValuesAlreadySet(t *testing.T) {
+	// Given
+	path := writeCookiesFile(t, "cookies.txt",
+		".leetcode.com\tTRUE\t/\tTRUE\t0\tLEETCODE_SESSION\tFILE_SESSION\n"+
+			".leetcode.com\tTRUE\t/\tTRUE\t0\tcsrftoken\tFILE_CSRF\n")
+	cfg := Config{LcSite: "com", LcCookie: "FLAG_SESSION"}
+
+	// When
+	err := cfg.LoadCookiesFile(path)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "FLAG_SESSION", cfg.LcCookie)
+	assert.Equal(t, "FILE_CSRF", cfg.LcCsrfToken)
+}
+
+func TestLoadCookiesFileShouldFailWithoutAnUnexpiredSessionCookie(t *testing.T) {
+	for name, content := range map[string]string{
+		"expired":      ".leetcode.com\tTRUE\t/\tTRUE\t1600000000\tLEETCODE_SESSION\tOLD_SESSION\n",
+		"other domain": ".leetcode.cn\tTRUE\t/\tTRUE\t0\tLEETCODE_SESSION\tCN_SESSION\n",
+		"lookalike":    ".notleetcode.com\tTRUE\t/\tTRUE\t0\tLEETCODE_SESSION\tOTHER_SESSION\n",
+		"invalid line": "leetcode.com\tLEETCODE_SESSION\tSESSION\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			path := writeCookiesFile(t, "cookies.txt", content)
+			cfg := Config{LcSite: "com"}
+
+			// When
+			err := cfg.LoadCookiesFile(path)
+
+			// Then
+			assert.Error(t, err)
+			assert.Empty(t, cfg.LcCookie)
+		})
+	}
+}
+
+func writeCookiesFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
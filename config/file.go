@@ -0,0 +1,46 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFilePath returns the config file read when no other one is given, ~/.config/glsync/config.yaml,
+// or an empty string if the home folder is unknown
+func DefaultFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "glsync", "config.yaml")
+}
+
+// LoadFile sets the options found in the YAML config file at path, the options missing from it are kept
+// as they are. A missing file is only an error if it's required, e.g. when it was passed explicitly.
+func (c *Config) LoadFile(path string, required bool) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't read the config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true) // Fail on misspelled options instead of silently ignoring them
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("couldn't parse the config file %v: %w", path, err)
+	}
+	return nil
+}
+
+// Yaml returns c in the format of the config file
+func (c Config) Yaml() ([]byte, error) {
+	return yaml.Marshal(c)
+}
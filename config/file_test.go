@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFileShouldOnlySetTheOptionsInTheFile(t *testing.T) {
+	// Given
+	path := writeConfigFile(t, "repo-url: git@github.com:user/repo.git\n"+
+		"site: cn\n"+
+		"all-submissions: true\n"+
+		"concurrency: 4\n"+
+		"rate-limit-window: 10m\n")
+	cfg := Config{LcSite: "com", Concurrency: 1, LcCookie: "FLAG_SESSION"}
+
+	// When
+	err := cfg.LoadFile(path, true)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		LcCookie: "FLAG_SESSION", RepoUrl: "git@github.com:user/repo.git", LcSite: "cn",
+		AllSubmissions: true, Concurrency: 4, RateLimitWindow: 10 * time.Minute,
+	}, cfg)
+}
+
+func TestLoadFileShouldIgnoreAMissingFileUnlessRequired(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := Config{LcSite: "com"}
+
+	// When
+	optionalErr := cfg.LoadFile(path, false)
+	requiredErr := cfg.LoadFile(path, true)
+
+	// Then
+	assert.NoError(t, optionalErr)
+	assert.Error(t, requiredErr)
+	assert.Equal(t, Config{LcSite: "com"}, cfg)
+}
+
+func TestLoadFileShouldAcceptAnEmptyFile(t *testing.T) {
+	// Given
+	path := writeConfigFile(t, "# Nothing set yet\n")
+	cfg := Config{LcSite: "com"}
+
+	// When
+	err := cfg.LoadFile(path, true)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, Config{LcSite: "com"}, cfg)
+}
+
+func TestLoadFileShouldFailOnInvalidOptions(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown option":   "repo_url: git@github.com:user/repo.git\n",
+		"invalid duration": "retry-max-delay: soon\n",
+		"invalid yaml":     "site: [com\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			path := writeConfigFile(t, content)
+			cfg := Config{}
+
+			// When
+			err := cfg.LoadFile(path, true)
+
+			// Then
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestYamlShouldRoundTripTheRedactedConfig(t *testing.T) {
+	// Given
+	cfg := Config{LcCookie: "SESSION", RepoUrl: "REPO_URL", LcSite: "cn", LcCsrfToken: "CSRF", RetryMaxDelay: time.Minute}
+
+	// When
+	content, err := cfg.Redacted().Yaml()
+	require.NoError(t, err)
+	loaded := Config{}
+	require.NoError(t, loaded.LoadFile(writeConfigFile(t, string(content)), true))
+
+	// Then
+	assert.NotContains(t, string(content), "SESSION")
+	assert.NotContains(t, string(content), "CSRF")
+	assert.Equal(t, Config{LcCookie: redacted, RepoUrl: "REPO_URL", LcSite: "cn", LcCsrfToken: redacted, RetryMaxDelay: time.Minute}, loaded)
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
@@ -0,0 +1,140 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// Checks of what the git clients need to commit and push, run by the doctor command before a sync fails on them
+
+// Version returns the output of 'git --version', failing if git isn't installed
+func Version(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "--version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("couldn't run 'git --version': %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CheckIdentity fails if user.name or user.email isn't set, as git refuses to commit without them.
+// They're read from the config of the checkout at repoPath if it's set, as it's committed into then.
+func CheckIdentity(ctx context.Context, repoPath string) (string, error) {
+	var values, missing []string
+	for _, key := range []string{"user.name", "user.email"} {
+		cmd := exec.CommandContext(ctx, "git", "config", "--get", key)
+		cmd.Dir = os.TempDir() // The clone doesn't get the local config of the repo glsync may run in
+		if repoPath != "" {
+			cmd.Dir = repoPath
+		}
+		out, err := cmd.Output()
+		value := strings.TrimSpace(string(out))
+		if err != nil || value == "" {
+			missing = append(missing, key)
+			continue
+		}
+		values = append(values, value)
+	}
+	if len(missing) > 0 {
+		verb := " isn't"
+		if len(missing) > 1 {
+			verb = " aren't"
+		}
+		return "", errors.New(strings.Join(missing, " and ") + verb + " set in git's config")
+	}
+	return strings.Join(values, " <") + ">", nil
+}
+
+// CheckPureGitIdentity is CheckIdentity for puregit, it reads the identity the way puregit does so it
+// works without git and takes the GIT_AUTHOR_* and GIT_COMMITTER_* environment variables into account
+func CheckPureGitIdentity(repoPath string) (string, error) {
+	// A repo without a local config has the identity of a new clone
+	repo, err := gogit.Init(memory.NewStorage(), nil)
+	if repoPath != "" {
+		repo, err = gogit.PlainOpenWithOptions(repoPath, &gogit.PlainOpenOptions{DetectDotGit: true})
+	}
+	if err != nil {
+		return "", fmt.Errorf("couldn't open the repo path %s: %w", repoPath, err)
+	}
+	author, _, err := identitiesOf(repo)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s <%s>", author.Name, author.Email), nil
+}
+
+// CheckRemote fails if the repo at url can't be reached with the credentials git clones it with
+func CheckRemote(ctx context.Context, url string) error {
+	// Inside a work tree git resolves relative paths from its top folder instead of the current one like clone does
+	url = absLocalUrl(url)
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--heads", url)
+	cmd.Env = append(cmd.Environ(), "GIT_TERMINAL_PROMPT=0") // Fail instead of waiting for a password
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("couldn't reach the repo %v: %w with output: %s", url, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// CheckCheckout fails if repoPath isn't a git checkout or the remote it pushes to can't be reached
+func CheckCheckout(ctx context.Context, repoPath string) error {
+	if _, err := checkoutRoot(ctx, repoPath); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "ls-remote", "--heads")
+	cmd.Env = append(cmd.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("couldn't reach the remote of the checkout %v: %w with output: %s", repoPath, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// CheckPureGitRemote is CheckRemote for puregit, it reaches the repo at url with go-git and the credentials
+// puregit clones it with, so it works without git
+func CheckPureGitRemote(ctx context.Context, url string) error {
+	return checkPureGitRemote(ctx, absLocalUrl(url))
+}
+
+// CheckPureGitCheckout is CheckCheckout for puregit, it reaches the origin remote of the checkout
+// repoPath is in with go-git, which puregit pushes to
+func CheckPureGitCheckout(ctx context.Context, repoPath string) error {
+	repo, err := gogit.PlainOpenWithOptions(repoPath, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("the repo path %s isn't a git checkout: %w", repoPath, err)
+	}
+	origin, err := repo.Remote(gogit.DefaultRemoteName)
+	if err != nil || len(origin.Config().URLs) == 0 {
+		return fmt.Errorf("the checkout %s has no %s remote to push to", repoPath, gogit.DefaultRemoteName)
+	}
+	return checkPureGitRemote(ctx, origin.Config().URLs[0])
+}
+
+// Lists the branches of the repo at url like puregit does before cloning it
+func checkPureGitRemote(ctx context.Context, url string) error {
+	auth, err := authOf(url)
+	if err != nil {
+		return err
+	}
+	if _, err := isEmptyRemote(ctx, url, auth); err != nil {
+		return fmt.Errorf("couldn't reach the repo %v: %w", url, err)
+	}
+	return nil
+}
+
+// Returns the absolute path of url if it's the path of a local repo, otherwise url as it is
+func absLocalUrl(url string) string {
+	if _, err := os.Stat(url); err == nil {
+		if absUrl, err := filepath.Abs(url); err == nil {
+			return absUrl
+		}
+	}
+	return url
+}
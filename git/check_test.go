@@ -0,0 +1,173 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionShouldReturnGitVersion(t *testing.T) {
+	// When
+	version, err := Version(context.Background())
+
+	// Then
+	require.NoError(t, err)
+	assert.Contains(t, version, "git version")
+}
+
+func TestCheckIdentityShouldReturnNameAndEmail(t *testing.T) {
+	// Given
+	useGlobalGitConfig(t, "[user]\n\tname = Jane\n\temail = jane@example.com\n")
+
+	// When
+	identity, err := CheckIdentity(context.Background(), "")
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "Jane <jane@example.com>", identity)
+}
+
+func TestCheckIdentityShouldFailWhenEmailIsMissing(t *testing.T) {
+	// Given
+	useGlobalGitConfig(t, "[user]\n\tname = Jane\n")
+
+	// When
+	_, err := CheckIdentity(context.Background(), "")
+
+	// Then
+	assert.ErrorContains(t, err, "user.email")
+	assert.NotContains(t, err.Error(), "user.name")
+}
+
+func TestCheckIdentityShouldReadTheConfigOfTheRepoPath(t *testing.T) {
+	// Given
+	useGlobalGitConfig(t, "[user]\n\tname = Jane\n")
+	checkout := t.TempDir()
+	out, err := exec.Command("git", "init", checkout).CombinedOutput()
+	require.NoError(t, err, string(out))
+	out, err = exec.Command("git", "-C", checkout, "config", "user.email", "jane@example.com").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// When
+	identity, err := CheckIdentity(context.Background(), checkout)
+	_, withoutRepoPathErr := CheckIdentity(context.Background(), "")
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "Jane <jane@example.com>", identity)
+	assert.ErrorContains(t, withoutRepoPathErr, "user.email")
+}
+
+func TestCheckPureGitIdentityShouldReadTheConfigGoGitReads(t *testing.T) {
+	// Given
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, role := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+role+"_NAME", "")
+		t.Setenv("GIT_"+role+"_EMAIL", "")
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[user]\n\tname = Jane\n"), 0o600))
+	checkout := t.TempDir()
+	out, err := exec.Command("git", "init", checkout).CombinedOutput()
+	require.NoError(t, err, string(out))
+	out, err = exec.Command("git", "-C", checkout, "config", "user.email", "jane@example.com").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// When
+	identity, err := CheckPureGitIdentity(checkout)
+	_, withoutRepoPathErr := CheckPureGitIdentity("")
+	t.Setenv("GIT_AUTHOR_EMAIL", "env@example.com")
+	t.Setenv("GIT_COMMITTER_EMAIL", "env@example.com")
+	envIdentity, envErr := CheckPureGitIdentity("")
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "Jane <jane@example.com>", identity)
+	assert.ErrorContains(t, withoutRepoPathErr, "user.email")
+	require.NoError(t, envErr)
+	assert.Equal(t, "Jane <env@example.com>", envIdentity)
+}
+
+func TestCheckRemoteShouldReachALocalBareRepo(t *testing.T) {
+	// Given
+	bareRepo := filepath.Join(t.TempDir(), "repo.git")
+	out, err := exec.Command("git", "init", "--bare", bareRepo).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// When
+	err = CheckRemote(context.Background(), bareRepo)
+	missingErr := CheckRemote(context.Background(), filepath.Join(t.TempDir(), "missing.git"))
+
+	// Then
+	assert.NoError(t, err)
+	assert.Error(t, missingErr)
+}
+
+func TestCheckCheckoutShouldReachTheRemoteOfACheckout(t *testing.T) {
+	// Given
+	bareRepo := filepath.Join(t.TempDir(), "repo.git")
+	checkout := filepath.Join(t.TempDir(), "repo")
+	out, err := exec.Command("git", "init", "--bare", bareRepo).CombinedOutput()
+	require.NoError(t, err, string(out))
+	out, err = exec.Command("git", "clone", bareRepo, checkout).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// When
+	err = CheckCheckout(context.Background(), checkout)
+	notCheckoutErr := CheckCheckout(context.Background(), t.TempDir())
+
+	// Then
+	assert.NoError(t, err)
+	assert.ErrorContains(t, notCheckoutErr, "isn't a git checkout")
+}
+
+func TestCheckPureGitRemoteShouldReachALocalBareRepo(t *testing.T) {
+	// Given
+	bareRepo := filepath.Join(t.TempDir(), "repo.git")
+	out, err := exec.Command("git", "init", "--bare", bareRepo).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// When
+	err = CheckPureGitRemote(context.Background(), bareRepo)
+	missingErr := CheckPureGitRemote(context.Background(), filepath.Join(t.TempDir(), "missing.git"))
+
+	// Then
+	assert.NoError(t, err)
+	assert.Error(t, missingErr)
+}
+
+func TestCheckPureGitCheckoutShouldReachTheOriginOfACheckout(t *testing.T) {
+	// Given
+	bareRepo := filepath.Join(t.TempDir(), "repo.git")
+	checkout := filepath.Join(t.TempDir(), "repo")
+	out, err := exec.Command("git", "init", "--bare", bareRepo).CombinedOutput()
+	require.NoError(t, err, string(out))
+	out, err = exec.Command("git", "clone", bareRepo, checkout).CombinedOutput()
+	require.NoError(t, err, string(out))
+	withoutOrigin := t.TempDir()
+	out, err = exec.Command("git", "init", withoutOrigin).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// When
+	err = CheckPureGitCheckout(context.Background(), checkout)
+	notCheckoutErr := CheckPureGitCheckout(context.Background(), t.TempDir())
+	withoutOriginErr := CheckPureGitCheckout(context.Background(), withoutOrigin)
+
+	// Then
+	assert.NoError(t, err)
+	assert.ErrorContains(t, notCheckoutErr, "isn't a git checkout")
+	assert.ErrorContains(t, withoutOriginErr, "no origin remote")
+}
+
+// Points git at a global config with content instead of the user's, ignoring the system config
+func useGlobalGitConfig(t *testing.T, content string) {
+	path := filepath.Join(t.TempDir(), "gitconfig")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	t.Setenv("GIT_CONFIG_GLOBAL", path)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
@@ -0,0 +1,221 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+)
+
+// fastimport clones the repo like gitcli, but instead of running 'git add' and 'git commit' for every
+// submission it buffers the commits and writes them all in one 'git fast-import' stream on Push.
+// The history is the same as gitcli's: the same trees, messages, identities and dates.
+type fastimport struct {
+	gitcli
+	author    string // "name <email>" as 'git commit' would set it
+	committer string
+	branch    string // The ref HEAD points at, which the commits go on
+	parent    string // The commit the first commit goes on, empty if the repo has no commits yet
+	stream    bytes.Buffer
+	commits   int
+	paths     []string // Paths of the files committed in the stream
+	// Hashes of the files on the branch by path, the commits in the stream included,
+	// so a file is unchanged if it's the same as in HEAD like for 'git commit', whatever the work tree has
+	blobs        map[string]string
+	objectFormat string // The hash of the repo's objects, sha1 or sha256
+}
+
+// Clones cfg.RepoUrl like NewGitCli, the commits are only written to the clone on Push
+func NewFastImport(ctx context.Context, cfg config.Config) (*fastimport, error) {
+	g, err := NewGitCli(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	f, err := newFastImport(ctx, g)
+	if err != nil {
+		g.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reads what the commits need from the repo of g: the identities to commit with, the branch and its last commit
+func newFastImport(ctx context.Context, g gitcli) (*fastimport, error) {
+	f := &fastimport{gitcli: g}
+	var err error
+	if f.author, err = f.ident(ctx, "GIT_AUTHOR_IDENT"); err != nil {
+		return nil, err
+	}
+	if f.committer, err = f.ident(ctx, "GIT_COMMITTER_IDENT"); err != nil {
+		return nil, err
+	}
+	out, err := f.command(ctx, "symbolic-ref", "HEAD").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't find the branch of the repo in folder %s: %w with command output: %s", f.repoPath, err, strings.TrimSpace(string(out)))
+	}
+	f.branch = strings.TrimSpace(string(out))
+	// Fails if the branch has no commits yet, the first commit is a root commit then
+	if out, err := f.command(ctx, "rev-parse", "--verify", "--quiet", "HEAD").Output(); err == nil {
+		f.parent = strings.TrimSpace(string(out))
+	}
+	out, err = f.command(ctx, "rev-parse", "--show-object-format").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the object format of the repo in folder %s: %w with command output: %s", f.repoPath, err, strings.TrimSpace(string(out)))
+	}
+	f.objectFormat = strings.TrimSpace(string(out))
+	if f.blobs, err = f.headBlobs(ctx); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Returns the hashes of the files of the parent commit by path, none if there is no parent
+func (f *fastimport) headBlobs(ctx context.Context) (map[string]string, error) {
+	blobs := map[string]string{}
+	if f.parent == "" {
+		return blobs, nil
+	}
+	out, err := f.command(ctx, "ls-tree", "-r", "-z", "--full-tree", f.parent).Output()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list the files of the repo in folder %s: %w", f.repoPath, err)
+	}
+	// Each entry is "<mode> <type> <hash>\t<path>"
+	for _, entry := range strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00") {
+		info, filePath, ok := strings.Cut(entry, "\t")
+		if fields := strings.Fields(info); ok && len(fields) == 3 {
+			blobs[filePath] = fields[2]
+		}
+	}
+	return blobs, nil
+}
+
+// Returns the hash git stores code with as a blob
+func (f *fastimport) blobHash(code string) string {
+	object := fmt.Sprintf("blob %d\x00%s", len(code), code)
+	if f.objectFormat == "sha256" {
+		sum := sha256.Sum256([]byte(object))
+		return hex.EncodeToString(sum[:])
+	}
+	sum := sha1.Sum([]byte(object))
+	return hex.EncodeToString(sum[:])
+}
+
+// Returns the "name <email>" of the author or committer identity, without the date 'git var' adds
+func (f *fastimport) ident(ctx context.Context, variable string) (string, error) {
+	out, err := f.command(ctx, "var", variable).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("couldn't read %s in the repo folder %s, is user.name and user.email set?: %w with command output: %s",
+			variable, f.repoPath, err, strings.TrimSpace(string(out)))
+	}
+	ident := strings.TrimSpace(string(out))
+	return ident[:strings.LastIndex(ident, ">")+1], nil
+}
+
+// Writes the code to the work tree and adds its commit to the stream, nothing is committed before Push.
+// Like 'git commit' it fails if the code didn't change or the message is empty.
+func (f *fastimport) Commit(ctx context.Context, folderName, fileName, code, commitMessage string, timestamp time.Time) error {
+	// gitcli passes the message as "-m <message>", so git commits it with a leading space
+	message := cleanupMessage(" " + commitMessage)
+	if message == "" {
+		return errors.New("aborting commit due to empty commit message")
+	}
+	filePath := filepath.Join(f.repoPath, folderName, fileName)
+	blob := f.blobHash(code)
+	if f.blobs[path.Join(folderName, fileName)] == blob {
+		return fmt.Errorf("%w, working tree clean", ErrNothingToCommit)
+	}
+	err := f.createCodeFolderAndFile(folderName, fileName, code)
+	if err != nil {
+		return fmt.Errorf("encountered the following error while creating the code folder and file:\n%v", err)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("couldn't read the mode of %s: %w", filePath, err)
+	}
+	// The mode 'git add' would stage the file with
+	mode := "100644"
+	if info.Mode()&0o111 != 0 {
+		mode = "100755"
+	}
+	date := f.toGitDate(timestamp)
+	fmt.Fprintf(&f.stream, "commit %s\n", f.branch)
+	fmt.Fprintf(&f.stream, "author %s %s\n", f.author, date)
+	fmt.Fprintf(&f.stream, "committer %s %s\n", f.committer, date)
+	fmt.Fprintf(&f.stream, "data %d\n%s", len(message), message)
+	if f.commits == 0 && f.parent != "" {
+		fmt.Fprintf(&f.stream, "from %s\n", f.parent)
+	}
+	fmt.Fprintf(&f.stream, "M %s inline %s\n", mode, path.Join(folderName, fileName))
+	fmt.Fprintf(&f.stream, "data %d\n%s\n", len(code), code)
+	f.commits++
+	f.paths = append(f.paths, path.Join(folderName, fileName))
+	f.blobs[path.Join(folderName, fileName)] = blob
+	return nil
+}
+
+// Writes the commits to the repo then pushes them and removes the local copy of the repo like gitcli
+func (f *fastimport) Push(ctx context.Context) error {
+	if err := f.importCommits(ctx); err != nil {
+		return err
+	}
+	return f.gitcli.Push(ctx)
+}
+
+// Runs 'git fast-import' with the commits buffered so far
+func (f *fastimport) importCommits(ctx context.Context) error {
+	if f.commits == 0 {
+		return nil
+	}
+	cmd := f.command(ctx, "fast-import", "--quiet")
+	cmd.Stdin = &f.stream
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("encountered an error while importing %v commits with 'git fast-import' in the repo folder %s: %w with command output: %s",
+			f.commits, f.repoPath, err, strings.TrimSpace(string(out)))
+	}
+	f.commits = 0
+	if out, err := f.command(ctx, "rev-parse", "HEAD").Output(); err == nil {
+		f.parent = strings.TrimSpace(string(out)) // Later commits go on the imported ones
+	}
+	// The work tree already has the files of the commits, only the index is behind them.
+	// Other paths are left alone as a checkout of the user may have changes staged.
+	reset := f.command(ctx, "reset", "--quiet", "--pathspec-from-file=-", "--pathspec-file-nul")
+	reset.Stdin = strings.NewReader(strings.Join(f.paths, "\x00"))
+	f.paths = nil
+	out, err = reset.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("couldn't reset the index of the repo folder %s: %w with command output: %s", f.repoPath, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Cleans up the message like 'git commit' does by default with -m: trailing whitespace and
+// leading and trailing empty lines are removed, consecutive empty lines are collapsed into one
+// and the message ends with a newline unless it's empty
+func cleanupMessage(message string) string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
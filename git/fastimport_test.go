@@ -0,0 +1,135 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCommit struct {
+	folderName, fileName, code, commitMessage string
+	timestamp                                 time.Time
+}
+
+func TestFastImportShouldCommitTheSameHistoryAsGitCli(t *testing.T) {
+	// Given
+	useCommitIdentity(t)
+	ctx := context.Background()
+	cli, fastImportRepo := newTestRepo(t), newTestRepo(t)
+	initialCommit := testCommit{"0001-two-sum", "solution.go", "package main\n", "initial commit", time.Unix(1700000000, 0).UTC()}
+	for _, g := range []gitcli{cli, fastImportRepo} {
+		require.NoError(t, g.Commit(ctx, initialCommit.folderName, initialCommit.fileName, initialCommit.code, initialCommit.commitMessage, initialCommit.timestamp))
+	}
+	fi, err := newFastImport(ctx, fastImportRepo)
+	require.NoError(t, err)
+	commits := []testCommit{
+		{"0128-longest-consecutive-sequence", "solution.java", "class Solution {}\n", "Code challenge submission for question: 128 Longest Consecutive Sequence", time.Unix(1735406731, 0).UTC()},
+		{"0001-two-sum", "solution.go", "package main\n\nfunc main() {}\n", "Code challenge submission for question: 1 Two Sum", time.Unix(1735406800, 0).In(time.FixedZone("", 2*60*60))},
+		{"0001-two-sum", "solution.py", "class Solution:\n    pass\n", "Code challenge submission for question: 1 Two Sum", time.Unix(1735406900, 0).UTC()},
+		{"0002-add-two-numbers", "solution.go", "package main\n", "Code challenge submission for question: 2 Add Two Numbers\n\nGlsync-Submission-Id: 200\nGlsync-Site: com", time.Unix(1735407000, 0).UTC()},
+	}
+
+	// When
+	for _, c := range commits {
+		require.NoError(t, cli.Commit(ctx, c.folderName, c.fileName, c.code, c.commitMessage, c.timestamp))
+		require.NoError(t, fi.Commit(ctx, c.folderName, c.fileName, c.code, c.commitMessage, c.timestamp))
+	}
+	unchanged := commits[len(commits)-1]
+	cliErr := cli.Commit(ctx, unchanged.folderName, unchanged.fileName, unchanged.code, unchanged.commitMessage, unchanged.timestamp)
+	fastImportErr := fi.Commit(ctx, unchanged.folderName, unchanged.fileName, unchanged.code, unchanged.commitMessage, unchanged.timestamp)
+	require.NoError(t, fi.importCommits(ctx))
+
+	// Then
+	assert.Equal(t, headOf(t, cli.repoPath), headOf(t, fi.repoPath))
+	assert.ErrorIs(t, cliErr, ErrNothingToCommit)
+	assert.ErrorIs(t, fastImportErr, ErrNothingToCommit)
+	status, err := exec.Command("git", "-C", fi.repoPath, "status", "--porcelain").CombinedOutput()
+	require.NoError(t, err, string(status))
+	assert.Empty(t, string(status))
+}
+
+func TestFastImportShouldCommitCodeTheWorkTreeAlreadyHasLikeGitCli(t *testing.T) {
+	// Given
+	useCommitIdentity(t)
+	ctx := context.Background()
+	cli, fastImportRepo := newTestRepo(t), newTestRepo(t)
+	initialCommit := testCommit{"0001-two-sum", "solution.go", "package main\n", "initial commit", time.Unix(1700000000, 0).UTC()}
+	edited := testCommit{"0001-two-sum", "solution.go", "package main\n\nfunc main() {}\n", "Code challenge submission for question: 1 Two Sum", time.Unix(1735406800, 0).UTC()}
+	for _, g := range []gitcli{cli, fastImportRepo} {
+		require.NoError(t, g.Commit(ctx, initialCommit.folderName, initialCommit.fileName, initialCommit.code, initialCommit.commitMessage, initialCommit.timestamp))
+		// An edit of the user in a checkout that isn't committed, with the code of the submission
+		require.NoError(t, os.WriteFile(filepath.Join(g.repoPath, edited.folderName, edited.fileName), []byte(edited.code), 0o644))
+	}
+	fi, err := newFastImport(ctx, fastImportRepo)
+	require.NoError(t, err)
+
+	// When
+	cliErr := cli.Commit(ctx, edited.folderName, edited.fileName, edited.code, edited.commitMessage, edited.timestamp)
+	fastImportErr := fi.Commit(ctx, edited.folderName, edited.fileName, edited.code, edited.commitMessage, edited.timestamp)
+	require.NoError(t, fi.importCommits(ctx))
+
+	// Then
+	require.NoError(t, cliErr)
+	require.NoError(t, fastImportErr)
+	assert.Equal(t, headOf(t, cli.repoPath), headOf(t, fi.repoPath))
+}
+
+func TestFastImportPushShouldPushTheCommitsToTheRemote(t *testing.T) {
+	// Given
+	useCommitIdentity(t)
+	ctx := context.Background()
+	bareRepo := filepath.Join(t.TempDir(), "repo.git")
+	clonePath := filepath.Join(t.TempDir(), "repo")
+	out, err := exec.Command("git", "init", "--bare", bareRepo).CombinedOutput()
+	require.NoError(t, err, string(out))
+	out, err = exec.Command("git", "clone", bareRepo, clonePath).CombinedOutput()
+	require.NoError(t, err, string(out))
+	fi, err := newFastImport(ctx, gitcli{cfg: g.cfg, repoPath: clonePath, workspace: filepath.Dir(clonePath)})
+	require.NoError(t, err)
+	require.NoError(t, fi.Commit(ctx, "0001-two-sum", "solution.go", "package main\n", "first", time.Now()))
+	require.NoError(t, fi.Commit(ctx, "0002-add-two-numbers", "solution.go", "package main\n", "second", time.Now()))
+
+	// When
+	err = fi.Push(ctx)
+
+	// Then
+	require.NoError(t, err)
+	log, err := exec.Command("git", "-C", bareRepo, "log", "--all", "--pretty=format:%s").CombinedOutput()
+	require.NoError(t, err, string(log))
+	assert.Equal(t, " second\n first", string(log)) // Like gitcli's, the messages start with a space
+	assert.NoDirExists(t, clonePath)
+}
+
+func TestFastImportCommitShouldFailWhenMessageIsEmpty(t *testing.T) {
+	// Given
+	useCommitIdentity(t)
+	fi, err := newFastImport(context.Background(), newTestRepo(t))
+	require.NoError(t, err)
+
+	// When
+	err = fi.Commit(context.Background(), "new-code-folder", "stub.go", "package main\n", "", time.Now())
+
+	// Then
+	assert.Error(t, err)
+}
+
+// Commits with the same identity whatever the git config of the machine is
+func useCommitIdentity(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "glsync")
+	t.Setenv("GIT_AUTHOR_EMAIL", "glsync@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "glsync")
+	t.Setenv("GIT_COMMITTER_EMAIL", "glsync@example.com")
+}
+
+func headOf(t *testing.T, repoPath string) string {
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD").CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
@@ -1,10 +1,57 @@
 // This package is responsible for committing and pushing the code to a git repo
-// It is currently implemented by [gitcli.go]
+// It is implemented by [gitcli.go], [fastimport.go] and [puregit.go], picked with [NewGitClient]
 package git
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+)
+
+// The names of the GitClient implementations, set in cfg.GitClient
+const (
+	CliClient        = "cli"         // gitcli, runs 'git add' and 'git commit' for each commit
+	FastImportClient = "fast-import" // fastimport, writes all the commits in one 'git fast-import' stream
+	GoGitClient      = "go-git"      // puregit, uses go-git so the git binary isn't needed
+)
+
+// The names of the GitClient implementations NewGitClient accepts
+var Clients = []string{CliClient, FastImportClient, GoGitClient}
+
+// NewGitClient clones cfg.RepoUrl with the GitClient implementation named cfg.GitClient, gitcli if it's empty
+func NewGitClient(ctx context.Context, cfg config.Config) (GitClient, error) {
+	switch cfg.GitClient {
+	case "", CliClient:
+		g, err := NewGitCli(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return g, nil
+	case FastImportClient:
+		return NewFastImport(ctx, cfg)
+	case GoGitClient:
+		return NewPureGit(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unknown git client %q", cfg.GitClient)
+	}
+}
+
+// Returned by Commit when the file already has the code, so there is nothing to commit
+var ErrNothingToCommit = errors.New("nothing to commit")
+
+// The git commands started by a GitClient are killed once ctx is done
 type GitClient interface {
-	Commit(folderName, fileName, code, commitMessage string, timestamp time.Time) error
-	Push() error
+	// Commits the code to the file, the error wraps ErrNothingToCommit if the file already has it
+	Commit(ctx context.Context, folderName, fileName, code, commitMessage string, timestamp time.Time) error
+	// Pushes the commits then removes the local copy of the repo like Close
+	Push(ctx context.Context) error
+	// Returns the content of a file committed before, the error wraps fs.ErrNotExist if there is no such file
+	ReadFile(folderName, fileName string) (string, error)
+	// Removes the local copy of the repo without pushing
+	Close() error
+	// Returns the ids of the submissions of site committed before, read from the trailers of the commits in the repo's history
+	SyncedSubmissionIds(ctx context.Context, site string) (map[string]bool, error)
 }
@@ -1,11 +1,14 @@
 package git
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -14,81 +17,136 @@ import (
 
 const commitDateEnvVar = "GIT_COMMITTER_DATE"
 
+// gitcli runs every git command in repoPath with -C and passes the commit dates in the command's own
+// environment, so it never changes the working directory or the environment of the process
+// and several of them can run side by side.
 type gitcli struct {
-	cfg            config.Config
-	repoFolderName string
+	cfg       config.Config
+	repoPath  string // Absolute path of the work tree
+	workspace string // Temp folder the repo was cloned in and Close removes, empty for a checkout of the user which is left in place
 }
 
-func NewGitCli(cfg config.Config) gitcli {
-	gh := gitcli{cfg: cfg}
-	url := strings.Split(gh.cfg.RepoUrl, "/")
-	gh.repoFolderName = strings.Split(url[len(url)-1], ".")[0]
-	if _, err := os.Stat(gh.repoFolderName); err == nil {
-		log.Printf(`Removing folder: [%v] as it is the same as the repo folder's name to be able to clone the repo`,
-			gh.repoFolderName)
-		os.RemoveAll(gh.repoFolderName)
-	}
-	log.Printf("Cloning %s next\n", gh.repoFolderName)
-	out, err := exec.Command("git", "clone", cfg.RepoUrl).CombinedOutput()
+// Clones cfg.RepoUrl into a new temp folder, or uses the checkout at cfg.RepoPath if it's set
+func NewGitCli(ctx context.Context, cfg config.Config) (gitcli, error) {
+	if cfg.RepoPath != "" {
+		repoPath, err := checkoutRoot(ctx, cfg.RepoPath)
+		return gitcli{cfg: cfg, repoPath: repoPath}, err
+	}
+	workspace, repoPath, err := newWorkspace(cfg.RepoUrl)
+	if err != nil {
+		return gitcli{cfg: cfg}, err
+	}
+	gh := gitcli{cfg: cfg, repoPath: repoPath, workspace: workspace}
+	log.Printf("Cloning %s next\n", filepath.Base(gh.repoPath))
+	out, err := exec.CommandContext(ctx, "git", "clone", cfg.RepoUrl, gh.repoPath).CombinedOutput()
+	if err != nil {
+		gh.Close()
+		return gh, fmt.Errorf(`couldn't clone the repo "%s", please create your repo on Git before using glsync.
+			The error: %w with command output: %s`, cfg.RepoUrl, err, strings.TrimSpace(string(out)))
+	}
+	log.Printf("Cloned %s successfully\n", filepath.Base(gh.repoPath))
+	return gh, nil
+}
+
+// Creates a temp folder to clone repoUrl in, returns it and the path of the clone in it named after the repo
+func newWorkspace(repoUrl string) (string, string, error) {
+	url := strings.Split(repoUrl, "/")
+	repoFolderName := strings.Split(url[len(url)-1], ".")[0]
+	workspace, err := os.MkdirTemp("", "glsync-")
 	if err != nil {
-		log.Println("Output ", string(out))
-		log.Println("Error", err.Error())
-		log.Panicf(
-			`Encountered an error while cloning the repo.
-			Please create your repo on Git before using glsync, the repo: "%s" doesn't exist`,
-			cfg.RepoUrl)
-	}
-	err = os.Chdir(gh.repoFolderName)
+		return "", "", fmt.Errorf("couldn't create a temp folder to clone the repo in: %w", err)
+	}
+	return workspace, filepath.Join(workspace, repoFolderName), nil
+}
+
+// Returns the absolute path of the top folder of the checkout repoPath is in, failing if it isn't in one
+func checkoutRoot(ctx context.Context, repoPath string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-parse", "--show-toplevel").CombinedOutput()
 	if err != nil {
-		log.Panicf("Couldn't chdir into repo folder %s. Please check permissions and try again", gh.repoFolderName)
+		return "", fmt.Errorf("the repo path %s isn't a git checkout: %w with command output: %s", repoPath, err, strings.TrimSpace(string(out)))
 	}
-	log.Printf("Cloned %s successfully\n", gh.repoFolderName)
-	return gh
+	return filepath.Abs(strings.TrimSpace(string(out)))
 }
 
-func (g gitcli) Commit(folderName, fileName, code, commitMessage string, timestamp time.Time) error {
+// Only the code's file is committed, other changes in the work tree are left as they are
+func (g gitcli) Commit(ctx context.Context, folderName, fileName, code, commitMessage string, timestamp time.Time) error {
 	err := g.createCodeFolderAndFile(folderName, fileName, code)
 	if err != nil {
 		return fmt.Errorf("encountered the following error while creating the code folder and file:\n%v", err)
 	}
-	out, err := exec.Command("git", "add", ".").CombinedOutput()
+	filePath := path.Join(folderName, fileName)
+	out, err := g.command(ctx, "add", "--", filePath).CombinedOutput()
 	if err != nil {
-		return fmt.Errorf(`encountered an error while executing the command 'git add .' in folder %s.
-			The error: %s with command output: %s`, g.repoFolderName, err, string(out))
+		return fmt.Errorf(`encountered an error while executing the command 'git add -- %s' in folder %s.
+			The error: %s with command output: %s`, filePath, g.repoPath, err, string(out))
+	}
+	// git commit fails for other reasons if there are other changes in the work tree
+	if err := g.command(ctx, "diff", "--cached", "--quiet", "--", filePath).Run(); err == nil {
+		return fmt.Errorf("%w, %s didn't change", ErrNothingToCommit, filePath)
 	}
-	os.Setenv(commitDateEnvVar, g.toGitDate(timestamp))
-	defer os.Unsetenv(commitDateEnvVar)
-	out, err = exec.Command("git", "commit", fmt.Sprintf("--date='%v'", g.toGitDate(timestamp)), fmt.Sprintf("-m %s", commitMessage)).CombinedOutput()
+	commit := g.command(ctx, "commit", fmt.Sprintf("--date='%v'", g.toGitDate(timestamp)), fmt.Sprintf("-m %s", commitMessage), "--", filePath)
+	commit.Env = append(commit.Environ(), commitDateEnvVar+"="+g.toGitDate(timestamp))
+	out, err = commit.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf(`encountered an error while executing the command 'git commit %s %s' in folder %s.
 			The error: %s 
 			with command output: %s`,
 			fmt.Sprintf("--date='%v'", g.toGitDate(timestamp)), fmt.Sprintf("-m %s", commitMessage),
-			g.repoFolderName, err, string(out))
+			g.repoPath, err, string(out))
 	}
 	return nil
 }
 
-func (g gitcli) Push() error {
-	err := exec.Command("git", "push").Run()
+func (g gitcli) Push(ctx context.Context) error {
+	err := g.command(ctx, "push").Run()
 	if err != nil {
-		return errors.New("encountered an error while doing the command 'git push' in the repo folder: " + g.repoFolderName)
+		return fmt.Errorf("encountered an error while doing the command 'git push' in the repo folder %s: %w", g.repoPath, err)
 	}
-	err = os.Chdir("..")
+	return g.Close()
+}
+
+func (g gitcli) ReadFile(folderName, fileName string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(g.repoPath, folderName, fileName))
 	if err != nil {
-		return errors.New("couldn't go back to the enclosing folder 'ch ..', could be a permissions issue")
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Removes the temp folder the repo was cloned in, a checkout of the user is left in place
+func (g gitcli) Close() error {
+	if g.workspace == "" {
+		return nil
 	}
-	err = os.RemoveAll(g.repoFolderName)
+	err := os.RemoveAll(g.workspace)
 	if err != nil {
-		return fmt.Errorf("couldn't delete the repo folder after pushing 'rm -rf %s', could be a permissions issue",
-			g.repoFolderName)
+		return fmt.Errorf("couldn't delete the repo folder 'rm -rf %s', could be a permissions issue",
+			g.workspace)
 	}
 	return nil
 }
 
+func (g gitcli) SyncedSubmissionIds(ctx context.Context, site string) (map[string]bool, error) {
+	// git log fails on a repo without commits, which has nothing synced
+	if err := g.command(ctx, "rev-parse", "--verify", "--quiet", "HEAD").Run(); err != nil {
+		return map[string]bool{}, nil
+	}
+	out, err := g.command(ctx, "log", "--format=%B%x00", "--fixed-strings", "--grep="+SubmissionIdTrailer+": ").Output()
+	if err != nil {
+		return nil, fmt.Errorf("encountered an error while reading the history of the repo folder %s with 'git log': %w", g.repoPath, err)
+	}
+	return syncedSubmissionIds(strings.Split(string(out), "\x00"), site), nil
+}
+
+// Returns the command running git with args in the repo folder
+func (g gitcli) command(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "git", append([]string{"-C", g.repoPath}, args...)...)
+}
+
 func (g gitcli) createCodeFolderAndFile(folderName string, fileName string, code string) error {
-	filePath := folderName + "/" + fileName
-	err := os.Mkdir(folderName, os.ModePerm)
+	folderPath := filepath.Join(g.repoPath, folderName)
+	filePath := filepath.Join(folderPath, fileName)
+	err := os.Mkdir(folderPath, os.ModePerm)
 	if err != nil && !os.IsExist(err) { // Ignore if file exists to update the file content
 		return err
 	}
@@ -100,6 +158,5 @@ func (g gitcli) createCodeFolderAndFile(folderName string, fileName string, code
 }
 
 func (g gitcli) toGitDate(timestamp time.Time) string {
-	_, offset := timestamp.Zone()
-	return fmt.Sprintf("%v %+05d", timestamp.Unix(), offset)
+	return fmt.Sprintf("%v %v", timestamp.Unix(), timestamp.Format("-0700"))
 }
@@ -1,11 +1,15 @@
 package git
 
 import (
-	"errors"
+	"context"
+	"io/fs"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -17,59 +21,33 @@ import (
 var g gitcli
 
 func TestMain(m *testing.M) {
-	testDir := createTestFolder()
-	output, err := exec.Command("git", "init").CombinedOutput()
+	testDir, err := os.MkdirTemp("", "test-gitcli-folder")
 	if err != nil {
-		log.Fatal(string(output)+" ", err)
-	}
-	g = gitcli{config.Config{LcCookie: "COOKIE", RepoUrl: "REPO_URL"}, testDir}
-	m.Run()
-	deleteTestFolder(testDir)
-}
-
-func deleteTestFolder(testDir string) {
-	// Go out of the current test folder and delete it
-	err := os.Chdir("..")
-	if err != nil {
-		log.Fatal("Couldn't `cd ..` to delete test folder")
-	}
-	err = os.RemoveAll(testDir)
-	if err != nil {
-		log.Fatal("Couldn't delete test folder: " + testDir)
+		log.Fatal(err)
 	}
-}
-
-func createTestFolder() string {
-	// Go to home directory
-	home, _ := os.UserHomeDir()
-
-	errArr := []error{os.Chdir(home)}
-
-	// Create and go into the test folder
-	testDir := "test-gitcli-folder"
-	errArr = append(errArr, os.Mkdir(testDir, os.ModePerm))
-	errArr = append(errArr, os.Chdir(home+"/"+testDir))
-
-	err := errors.Join(errArr...)
+	output, err := exec.Command("git", "init", testDir).CombinedOutput()
 	if err != nil {
-		log.Fatal(err)
+		log.Fatal(string(output)+" ", err)
 	}
-	return testDir
+	g = gitcli{cfg: config.Config{LcCookie: "COOKIE", RepoUrl: "REPO_URL"}, repoPath: testDir}
+	code := m.Run()
+	os.RemoveAll(testDir)
+	os.Exit(code)
 }
 
 func TestCommit(t *testing.T) {
 	// Given
 	codeFolderName, fileName, code, commitMessage, timestamp := "new-code-folder", "stub.go", "package main\n", "commit message", time.Now()
-	defer os.RemoveAll("new-code-folder")
+	defer os.RemoveAll(filepath.Join(g.repoPath, codeFolderName))
 
 	// When
-	err := g.Commit(codeFolderName, fileName, code, commitMessage, timestamp)
+	err := g.Commit(context.Background(), codeFolderName, fileName, code, commitMessage, timestamp)
 
 	// Then
 	// Verify that the folder and file of the code exists
 	assert.NoError(t, err)
-	assert.DirExists(t, codeFolderName)
-	filePath := codeFolderName + "/" + fileName
+	assert.DirExists(t, filepath.Join(g.repoPath, codeFolderName))
+	filePath := filepath.Join(g.repoPath, codeFolderName, fileName)
 	assert.FileExists(t, filePath)
 
 	//Veriy the code is correct
@@ -77,21 +55,37 @@ func TestCommit(t *testing.T) {
 	assert.Equal(t, code, string(actualCode))
 
 	// Verify the date of the commit is correct
-	actualTimestamp, actualCommitMessage := getCommitTimeAndMessage(t)
+	actualTimestamp, actualCommitMessage := getCommitTimeAndMessage(t, g.repoPath)
 	assert.Equal(t, commitMessage, actualCommitMessage)
 	assert.Equal(t, timestamp.Round(time.Minute), actualTimestamp.Round(time.Minute)) // Round to avoid partial second errors
 }
 
+func TestReadFileShouldReturnTheCommittedCode(t *testing.T) {
+	// Given
+	codeFolderName, fileName, code := "read-code-folder", "stub.go", "package main\n"
+	defer os.RemoveAll(filepath.Join(g.repoPath, codeFolderName))
+	require.NoError(t, g.Commit(context.Background(), codeFolderName, fileName, code, "commit message", time.Now()))
+
+	// When
+	actualCode, err := g.ReadFile(codeFolderName, fileName)
+	_, missingErr := g.ReadFile(codeFolderName, "missing.go")
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, code, actualCode)
+	assert.ErrorIs(t, missingErr, fs.ErrNotExist)
+}
+
 func TestCommitShouldFailWhenFolderCreationFails(t *testing.T) {
 	// Given
-	if err := os.Mkdir("alreadyexists", os.ModeDir); err != nil {
+	if err := os.Mkdir(filepath.Join(g.repoPath, "alreadyexists"), os.ModeDir); err != nil {
 		t.Error(err)
 	}
-	defer os.Remove("alreadyexists")
+	defer os.Remove(filepath.Join(g.repoPath, "alreadyexists"))
 	invalidFolderName, fileName, code, commitMessage, timestamp := "alreadyexists", "stub.go", "package main\n", "commit message", time.Now()
 
 	// When
-	err := g.Commit(invalidFolderName, fileName, code, commitMessage, timestamp)
+	err := g.Commit(context.Background(), invalidFolderName, fileName, code, commitMessage, timestamp)
 
 	// Then
 	require.Error(t, err)
@@ -102,38 +96,163 @@ func TestCommitShouldFailWhenFolderCreationFails(t *testing.T) {
 // I would genuinely love to know who does something like this
 func TestCommitShouldFailWhenGitAddFails(t *testing.T) {
 	// Given
-	originalDir, _ := os.Getwd()
 	// Not a git repo, so git add should fail
-	if err := os.Chdir(os.TempDir()); err != nil {
-		t.Error(err)
-	}
+	notRepo := gitcli{cfg: g.cfg, repoPath: t.TempDir()}
 	folderName, fileName, code, commitMessage, timestamp := "new-code-folder", "stub.go", "package main\n", "commit message", time.Now()
 
 	// When
-	err := g.Commit(folderName, fileName, code, commitMessage, timestamp)
+	err := notRepo.Commit(context.Background(), folderName, fileName, code, commitMessage, timestamp)
 
 	// Then
 	require.Error(t, err)
-	os.RemoveAll("new-code-folder")
-	if err = os.Chdir(originalDir); err != nil {
-		t.Error(err)
-	}
 }
 
 func TestCommitShouldFailWhenGitCommitFails(t *testing.T) {
 	// Given
 	codeFolderName, fileName, code, emptyCommitMessage, timestamp := "new-code-folder", "stub.go", "package main\n", "", time.Now()
-	defer os.RemoveAll("new-code-folder")
+	defer os.RemoveAll(filepath.Join(g.repoPath, codeFolderName))
 
 	// When
-	err := g.Commit(codeFolderName, fileName, code, emptyCommitMessage, timestamp)
+	err := g.Commit(context.Background(), codeFolderName, fileName, code, emptyCommitMessage, timestamp)
 
 	// Then
 	assert.Error(t, err)
 }
 
-func getCommitTimeAndMessage(t *testing.T) (time.Time, string) {
-	logOutputBytes, err := exec.Command("git", "log", "--pretty=format:'%ad|%s'", "--date=iso").CombinedOutput()
+func TestNewGitCliShouldCloneIntoATempFolderAndRemoveItOnPush(t *testing.T) {
+	// Given
+	useCommitIdentity(t)
+	ctx := context.Background()
+	bareRepo := newBareRepo(t, "glsync-clone-test.git")
+	// A folder named like the repo in the current folder, which glsync used to delete
+	require.NoError(t, os.MkdirAll("glsync-clone-test", os.ModePerm))
+	defer os.RemoveAll("glsync-clone-test")
+	require.NoError(t, os.WriteFile("glsync-clone-test/notes.txt", []byte("notes"), 0o644))
+
+	// When
+	clone, err := NewGitCli(ctx, config.Config{RepoUrl: bareRepo})
+	require.NoError(t, err)
+	require.NoError(t, clone.Commit(ctx, "0001-two-sum", "solution.go", "package main\n", "first", time.Now()))
+	err = clone.Push(ctx)
+
+	// Then
+	require.NoError(t, err)
+	assert.NotEqual(t, "glsync-clone-test", clone.repoPath)
+	assert.NoDirExists(t, clone.workspace)
+	assert.FileExists(t, "glsync-clone-test/notes.txt")
+	assert.Equal(t, " first", logOf(t, bareRepo))
+}
+
+func TestNewGitCliShouldCommitIntoTheCheckoutAtRepoPathAndLeaveItInPlace(t *testing.T) {
+	// Given
+	useCommitIdentity(t)
+	ctx := context.Background()
+	bareRepo := newBareRepo(t, "repo.git")
+	checkout := filepath.Join(t.TempDir(), "repo")
+	out, err := exec.Command("git", "clone", bareRepo, checkout).CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.NoError(t, os.WriteFile(filepath.Join(checkout, "notes.txt"), []byte("notes"), 0o644))
+
+	// When
+	existing, err := NewGitCli(ctx, config.Config{RepoPath: checkout})
+	require.NoError(t, err)
+	require.NoError(t, existing.Commit(ctx, "0001-two-sum", "solution.go", "package main\n", "first", time.Now()))
+	err = existing.Push(ctx)
+
+	// Then
+	require.NoError(t, err)
+	assert.DirExists(t, checkout)
+	status, err := exec.Command("git", "-C", checkout, "status", "--porcelain").CombinedOutput()
+	require.NoError(t, err, string(status))
+	assert.Equal(t, "?? notes.txt\n", string(status)) // Other changes aren't committed
+	assert.Equal(t, " first", logOf(t, bareRepo))
+}
+
+func TestNewGitCliShouldFailWhenRepoPathIsntACheckout(t *testing.T) {
+	// Given
+	notCheckout := t.TempDir()
+
+	// When
+	_, err := NewGitCli(context.Background(), config.Config{RepoPath: notCheckout})
+
+	// Then
+	assert.ErrorContains(t, err, "isn't a git checkout")
+	assert.DirExists(t, notCheckout)
+}
+
+func TestSyncedSubmissionIdsShouldReadTheTrailersOfTheHistory(t *testing.T) {
+	// Given
+	useCommitIdentity(t)
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	emptyIds, emptyErr := repo.SyncedSubmissionIds(ctx, "com")
+	require.NoError(t, repo.Commit(ctx, "0001-two-sum", "solution.go", "package main\n", "1 Two Sum\n\nGlsync-Submission-Id: 100\nGlsync-Site: com", time.Now()))
+	require.NoError(t, repo.Commit(ctx, "0001-two-sum", "solution.py", "pass\n", "1 Two Sum\n\nGlsync-Submission-Id: 200\nGlsync-Site: cn", time.Now()))
+	require.NoError(t, repo.Commit(ctx, "0002-add-two-numbers", "solution.go", "package main\n", "2 Add Two Numbers", time.Now()))
+
+	// When
+	ids, err := repo.SyncedSubmissionIds(ctx, "com")
+
+	// Then
+	require.NoError(t, emptyErr)
+	assert.Empty(t, emptyIds)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"100": true}, ids)
+}
+
+func TestCommitShouldKeepTheDatesOfConcurrentClientsApart(t *testing.T) {
+	// Given
+	clients := []gitcli{newTestRepo(t), newTestRepo(t)}
+	timestamps := []time.Time{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), time.Date(2023, 6, 7, 8, 9, 10, 0, time.UTC)}
+
+	// When
+	var wg sync.WaitGroup
+	errs := make([]error, len(clients))
+	for i, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = client.Commit(context.Background(), "folder", "stub.go", "package main\n", "commit message", timestamps[i])
+		}()
+	}
+	wg.Wait()
+
+	// Then
+	for i, client := range clients {
+		require.NoError(t, errs[i])
+		out, err := exec.Command("git", "-C", client.repoPath, "log", "--pretty=format:%ct").CombinedOutput()
+		require.NoError(t, err, string(out))
+		assert.Equal(t, strconv.FormatInt(timestamps[i].Unix(), 10), string(out))
+	}
+	_, isSet := os.LookupEnv(commitDateEnvVar)
+	assert.False(t, isSet)
+}
+
+// Returns a gitcli committing into a new repo in a temp folder
+func newTestRepo(t *testing.T) gitcli {
+	repoPath := t.TempDir()
+	out, err := exec.Command("git", "init", repoPath).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return gitcli{cfg: g.cfg, repoPath: repoPath}
+}
+
+// Returns the path of a new bare repo named name in a temp folder
+func newBareRepo(t *testing.T, name string) string {
+	bareRepo := filepath.Join(t.TempDir(), name)
+	out, err := exec.Command("git", "init", "--bare", bareRepo).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return bareRepo
+}
+
+// Returns the subjects of the commits of the repo, newest first
+func logOf(t *testing.T, repoPath string) string {
+	out, err := exec.Command("git", "-C", repoPath, "log", "--all", "--pretty=format:%s").CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+func getCommitTimeAndMessage(t *testing.T, repoPath string) (time.Time, string) {
+	logOutputBytes, err := exec.Command("git", "-C", repoPath, "log", "--pretty=format:'%ad|%s'", "--date=iso").CombinedOutput()
 	if err != nil {
 		t.Fatal("Failed to do git log command ", string(logOutputBytes), err)
 	}
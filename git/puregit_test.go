@@ -0,0 +1,175 @@
+package git
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPureGitCommit(t *testing.T) {
+	// Given
+	p := newTestPureGit(t)
+	codeFolderName, fileName, code, commitMessage, timestamp := "new-code-folder", "stub.go", "package main\n", "commit message", time.Now()
+
+	// When
+	err := p.Commit(context.Background(), codeFolderName, fileName, code, commitMessage, timestamp)
+
+	// Then
+	require.NoError(t, err)
+	actualCode, _ := os.ReadFile(filepath.Join(p.repoPath, codeFolderName, fileName))
+	assert.Equal(t, code, string(actualCode))
+	actualTimestamp, actualCommitMessage := getCommitTimeAndMessage(t, p.repoPath)
+	assert.Equal(t, commitMessage, actualCommitMessage)
+	assert.Equal(t, timestamp.Round(time.Minute), actualTimestamp.Round(time.Minute))
+}
+
+func TestPureGitShouldCommitTheSameHistoryAsGitCli(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	cli, p := newTestRepo(t), newTestPureGit(t)
+	commits := []testCommit{
+		{"0128-longest-consecutive-sequence", "solution.java", "class Solution {}\n", "Code challenge submission for question: 128 Longest Consecutive Sequence", time.Unix(1735406731, 0).UTC()},
+		{"0001-two-sum", "solution.go", "package main\n", "Code challenge submission for question: 1 Two Sum", time.Unix(1735406800, 0).In(time.FixedZone("", 2*60*60))},
+		{"0001-two-sum", "solution.go", "package main\n\nfunc main() {}\n", "Code challenge submission for question: 1 Two Sum", time.Unix(1735406900, 0).UTC()},
+		{"0002-add-two-numbers", "solution.go", "package main\n", "Code challenge submission for question: 2 Add Two Numbers\n\nGlsync-Submission-Id: 200\nGlsync-Site: com", time.Unix(1735407000, 0).UTC()},
+	}
+
+	// When
+	for _, c := range commits {
+		require.NoError(t, cli.Commit(ctx, c.folderName, c.fileName, c.code, c.commitMessage, c.timestamp))
+		require.NoError(t, p.Commit(ctx, c.folderName, c.fileName, c.code, c.commitMessage, c.timestamp))
+	}
+	unchanged := commits[len(commits)-1]
+	err := p.Commit(ctx, unchanged.folderName, unchanged.fileName, unchanged.code, unchanged.commitMessage, unchanged.timestamp)
+
+	cliIds, cliErr := cli.SyncedSubmissionIds(ctx, "com")
+	ids, idsErr := p.SyncedSubmissionIds(ctx, "com")
+
+	// Then
+	assert.Equal(t, headOf(t, cli.repoPath), headOf(t, p.repoPath))
+	assert.ErrorIs(t, err, ErrNothingToCommit)
+	require.NoError(t, cliErr)
+	require.NoError(t, idsErr)
+	assert.Equal(t, map[string]bool{"200": true}, cliIds)
+	assert.Equal(t, map[string]bool{"200": true}, ids)
+}
+
+func TestPureGitSyncedSubmissionIdsShouldBeEmptyWithoutCommits(t *testing.T) {
+	// Given
+	p := newTestPureGit(t)
+
+	// When
+	ids, err := p.SyncedSubmissionIds(context.Background(), "com")
+
+	// Then
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestPureGitCommitShouldFailWhenMessageIsEmpty(t *testing.T) {
+	// Given
+	p := newTestPureGit(t)
+
+	// When
+	err := p.Commit(context.Background(), "new-code-folder", "stub.go", "package main\n", "", time.Now())
+
+	// Then
+	assert.Error(t, err)
+}
+
+func TestNewPureGitShouldCloneAndPushToALocalBareRepo(t *testing.T) {
+	// Given
+	useCommitIdentity(t)
+	ctx := context.Background()
+	bareRepo := newBareRepo(t, "puregit-test.git")
+	cfg := config.Config{RepoUrl: bareRepo}
+
+	// When
+	// The repo has no commits on the first clone
+	first, err := NewPureGit(ctx, cfg)
+	require.NoError(t, err)
+	require.NoError(t, first.Commit(ctx, "0001-two-sum", "solution.go", "package main\n", "first", time.Now()))
+	require.NoError(t, first.Push(ctx))
+	second, err := NewPureGit(ctx, cfg)
+	require.NoError(t, err)
+	code, readErr := second.ReadFile("0001-two-sum", "solution.go")
+	_, missingErr := second.ReadFile("0001-two-sum", "missing.go")
+	require.NoError(t, second.Commit(ctx, "0002-add-two-numbers", "solution.go", "package main\n", "second", time.Now()))
+	require.NoError(t, second.Push(ctx))
+
+	// Then
+	require.NoError(t, readErr)
+	assert.Equal(t, "package main\n", code)
+	assert.ErrorIs(t, missingErr, fs.ErrNotExist)
+	assert.Equal(t, " second\n first", logOf(t, bareRepo)) // Like gitcli's, the messages start with a space
+	assert.NoDirExists(t, first.workspace)
+	assert.NoDirExists(t, second.workspace)
+}
+
+func TestNewPureGitShouldCommitIntoTheCheckoutAtRepoPathAndLeaveItInPlace(t *testing.T) {
+	// Given
+	useCommitIdentity(t)
+	ctx := context.Background()
+	bareRepo := newBareRepo(t, "repo.git")
+	checkout := filepath.Join(t.TempDir(), "repo")
+	out, err := exec.Command("git", "clone", bareRepo, checkout).CombinedOutput()
+	require.NoError(t, err, string(out))
+	// A local branch of the user, which the sync must not push
+	for _, args := range [][]string{
+		{"commit", "--allow-empty", "-m", "base"},
+		{"checkout", "-b", "private-wip"},
+		{"commit", "--allow-empty", "-m", "wip"},
+		{"checkout", "-"},
+	} {
+		out, err = exec.Command("git", append([]string{"-C", checkout}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	// When
+	existing, err := NewPureGit(ctx, config.Config{RepoPath: checkout})
+	require.NoError(t, err)
+	require.NoError(t, existing.Commit(ctx, "0001-two-sum", "solution.go", "package main\n", "first", time.Now()))
+	err = existing.Push(ctx)
+
+	// Then
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(checkout, "0001-two-sum", "solution.go"))
+	assert.Equal(t, " first\nbase", logOf(t, bareRepo))
+	assert.Error(t, exec.Command("git", "-C", bareRepo, "rev-parse", "--verify", "refs/heads/private-wip").Run())
+}
+
+func TestNewPureGitShouldFailWhenTheCheckoutHasStagedChanges(t *testing.T) {
+	// Given
+	checkout := t.TempDir()
+	out, err := exec.Command("git", "init", checkout).CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.NoError(t, os.WriteFile(filepath.Join(checkout, "notes.txt"), []byte("notes"), 0o644))
+	out, err = exec.Command("git", "-C", checkout, "add", "notes.txt").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// When
+	_, err = NewPureGit(context.Background(), config.Config{RepoPath: checkout})
+
+	// Then
+	assert.ErrorContains(t, err, "staged changes")
+}
+
+// Returns a puregit committing into a new repo in a temp folder, with the identity set by useCommitIdentity
+func newTestPureGit(t *testing.T) *puregit {
+	useCommitIdentity(t)
+	g := newTestRepo(t)
+	repo, err := gogit.PlainOpen(g.repoPath)
+	require.NoError(t, err)
+	p, err := newPureGit(g, repo, nil)
+	require.NoError(t, err)
+	return p
+}
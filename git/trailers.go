@@ -0,0 +1,35 @@
+package git
+
+import "strings"
+
+// The trailers ending the message of a submission's commit, which SyncedSubmissionIds reads back
+// to know what was committed before whatever the files look like now
+const (
+	SubmissionIdTrailer = "Glsync-Submission-Id"
+	SiteTrailer         = "Glsync-Site" // The LeetCode site of the submission, its ids aren't unique across sites
+)
+
+// Returns the submission ids of site in the trailers of messages
+func syncedSubmissionIds(messages []string, site string) map[string]bool {
+	ids := map[string]bool{}
+	for _, message := range messages {
+		trailers := parseTrailers(message)
+		if id := trailers[SubmissionIdTrailer]; id != "" && trailers[SiteTrailer] == site {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// Returns the "Key: value" trailers in the last paragraph of message, keyed by their key
+func parseTrailers(message string) map[string]string {
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	trailers := map[string]string{}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if ok && key != "" && !strings.ContainsAny(key, " \t") {
+			trailers[key] = strings.TrimSpace(value)
+		}
+	}
+	return trailers
+}
@@ -0,0 +1,24 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncedSubmissionIdsShouldReadTheTrailersOfTheSite(t *testing.T) {
+	// Given
+	messages := []string{
+		" Code challenge submission for question: 1 Two Sum\n\nGlsync-Submission-Id: 100\nGlsync-Site: com\n",
+		" Code challenge submission for question: 1 Two Sum\n\nGlsync-Submission-Id: 200\nGlsync-Site: cn\n",
+		" Code challenge submission for question: 2 Add Two Numbers\n",
+		"Glsync-Submission-Id: 300 in the subject isn't a trailer\n\nGlsync-Site: com\n",
+		"",
+	}
+
+	// When
+	ids := syncedSubmissionIds(messages, "com")
+
+	// Then
+	assert.Equal(t, map[string]bool{"100": true}, ids)
+}
@@ -1,87 +1,227 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/ahmed-e-abdulaziz/glsync/code"
+	"github.com/ahmed-e-abdulaziz/glsync/config"
 	"github.com/ahmed-e-abdulaziz/glsync/git"
+	"github.com/ahmed-e-abdulaziz/glsync/state"
+)
+
+// Errors the handler wraps the errors it returns with, so callers can tell which step of a sync failed
+var (
+	// Fetching the submissions failed, the error also wraps the code client's error
+	ErrFetchFailed = errors.New("couldn't fetch the code submissions")
+	// A git command failed, such as cloning, reading or pushing the repo
+	ErrGitFailed = errors.New("git failed")
+	// Some submissions couldn't be fetched or committed, the others were committed and pushed
+	ErrPartialSync = errors.New("some submissions couldn't be synced")
 )
 
 type Handler struct {
+	cfg        config.Config
 	codeClient code.CodeClient
 	git        git.GitClient
 }
 
-func NewHandler(codeClient code.CodeClient, gitClient git.GitClient) Handler {
-	return Handler{codeClient, gitClient}
+func NewHandler(cfg config.Config, codeClient code.CodeClient, gitClient git.GitClient) Handler {
+	return Handler{cfg, codeClient, gitClient}
 }
 
 // It does three things:
 //
 //	1- Fetch submissions using codeClient
-//	2- Loop through submissions and git commit each one
+//	2- Loop through submissions and git commit each one in the order they were fetched,
+//	   so multiple submissions of the same question show how its solution changed over time
 //	3- Use git to push to the repo set in the git client
-func (h Handler) Execute() {
-	submissions, err := h.codeClient.FetchSubmissions()
-	if err != nil {
-		panic("Error while fetching code submissions: " + err.Error())
+//
+// Each commit ends with Glsync-Submission-Id and Glsync-Site trailers, and submissions the repo's history
+// has a commit of are skipped, so running it again is a no-op even if the files were edited since.
+//
+// If cfg.StateFile is set, only questions submitted since the last sync are fetched and submissions
+// that were committed in a previous run are skipped. The state file is updated after a successful push.
+//
+// A question whose submissions fail to be fetched or a submission that fails to be committed doesn't stop
+// the sync, the others are still committed and pushed and ErrPartialSync is returned. The last sync time
+// in the state file isn't moved then, so the next run fetches the failed submissions again.
+//
+// Once ctx is done it stops committing and returns without pushing, the state file is left as it was.
+func (h Handler) Execute(ctx context.Context) error {
+	st := h.loadState()
+	syncStartedAt := time.Now()
+	submissions, err := h.codeClient.FetchSubmissions(ctx, st.LastSyncedAt)
+	var fetchErr error
+	if errors.Is(err, code.ErrPartialFetch) {
+		log.Printf("Warning: %v, committing the submissions of the other questions\n", err)
+		fetchErr = err
+	} else if err != nil {
+		return fmt.Errorf("%w: %w", ErrFetchFailed, err)
 	}
 	log.Printf("Fetched %v submissions, will commit them next\n", len(submissions))
+	syncedSubmissions := st.SyncedSubmissions()
+	committedSubmissions, err := h.git.SyncedSubmissionIds(ctx, h.site())
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrGitFailed, err)
+	}
+	failed := 0
 	for idx, s := range submissions {
-		// ex. s.Id="10", s.TitleSlug="binary-tree", s.Lang="go" then fileName = "10binary-tree.go"
-		fileName := h.buildFileName(s.Id, s.TitleSlug, s.Lang)
-		// ex. s.Id="10", s.Title="Binary Tree", then folderName = "10 Binary Tree"
-		folderName := fmt.Sprintf("%v %v", s.Id, s.Title)
+		if ctx.Err() != nil {
+			return fmt.Errorf("sync was interrupted after committing %v of %v submissions: %w", idx, len(submissions), context.Cause(ctx))
+		}
+		if s.SubmissionId != "" && (syncedSubmissions[s.SubmissionId] || committedSubmissions[s.SubmissionId]) {
+			log.Printf("\tSkipping submission %v for question with ID: %v as it was synced before\n", s.SubmissionId, s.Id)
+			continue
+		}
+		folderName, fileName := h.buildPath(s)
 		// ex. s.Id="10", s.Title="Binary Tree", then commitName = "Code challenge submission for question: 10 Binary Tree"
 		commitName := fmt.Sprintf("Code challenge submission for question: %v %v", s.Id, s.Title)
-		err := h.git.Commit(folderName, fileName, s.Code, commitName, s.LastSubmittedAt)
-		if err != nil && !strings.Contains(err.Error(), "nothing to commit") {
+		if s.SubmissionId != "" {
+			// Read back by SyncedSubmissionIds so later runs skip the submission
+			commitName += fmt.Sprintf("\n\n%v: %v\n%v: %v", git.SubmissionIdTrailer, s.SubmissionId, git.SiteTrailer, h.site())
+		}
+		err := h.git.Commit(ctx, folderName, fileName, s.Code, commitName, s.LastSubmittedAt)
+		if err != nil && !errors.Is(err, git.ErrNothingToCommit) {
 			log.Println("\t" + err.Error())
 			log.Printf("\tEncountered an error while commiting the code for question with ID: %v\n", s.Id)
+			failed++
+		} else if s.SubmissionId != "" {
+			st.SubmissionIds = append(st.SubmissionIds, s.SubmissionId)
 		}
-		log.Printf("\t%v%% questions committed. Committed question no. %v of total %v with ID: %v\n", int(float64(idx+1)/float64(len(submissions))*100), idx+1, len(submissions), s.Id)
+		log.Printf("\t%v%% submissions committed. Committed submission no. %v of total %v for question with ID: %v\n", int(float64(idx+1)/float64(len(submissions))*100), idx+1, len(submissions), s.Id)
+	}
+	err = h.git.Push(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrGitFailed, err)
+	}
+	var problems []string
+	if failed > 0 {
+		problems = append(problems, fmt.Sprintf("%v of %v submissions failed", failed, len(submissions)))
+	}
+	if fetchErr != nil {
+		// Not wrapped, the exit code has to be the partial sync one whatever the questions failed with
+		problems = append(problems, fetchErr.Error())
+	}
+	if len(problems) > 0 {
+		h.saveState(st)
+		return fmt.Errorf("%w, %v", ErrPartialSync, strings.Join(problems, ", "))
+	}
+	st.LastSyncedAt = syncStartedAt
+	h.saveState(st)
+	return nil
+}
+
+// Returns the LeetCode site the submissions are from, "com" like the -site flag if cfg doesn't set it
+func (h Handler) site() string {
+	if h.cfg.LcSite == "" {
+		return "com"
+	}
+	return h.cfg.LcSite
+}
+
+// Loads the sync state from cfg.StateFile, returns an empty state which means a full sync
+// if no state file is configured or it couldn't be loaded
+func (h Handler) loadState() state.State {
+	if h.cfg.StateFile == "" {
+		return state.State{}
 	}
-	err = h.git.Push()
+	st, err := state.Load(h.cfg.StateFile)
 	if err != nil {
-		panic("Encountered an error while pushing to git, exiting...")
+		log.Printf("Warning: %v, doing a full sync instead\n", err)
+		return state.State{}
+	}
+	if !st.LastSyncedAt.IsZero() {
+		log.Printf("Loaded sync state, last synced at %v\n", st.LastSyncedAt.Format(time.RFC3339))
 	}
+	return st
+}
+
+func (h Handler) saveState(st state.State) {
+	if h.cfg.StateFile == "" {
+		return
+	}
+	if err := st.Save(h.cfg.StateFile); err != nil {
+		log.Printf("Warning: %v, the next run will sync everything again\n", err)
+	}
+}
+
+// Returns the folder and file s is committed to
+func (h Handler) buildPath(s code.Submission) (folderName, fileName string) {
+	// ex. s.Id="10", s.TitleSlug="binary-tree", s.Lang="go" then fileName = "10binary-tree.go"
+	fileName = h.buildFileName(s.Id, s.TitleSlug, s.Lang)
+	// Only on the language so a file keeps its name whichever languages a later sync fetches
+	if h.cfg.AllLanguages && sharedExtensions[langFileExtension[s.Lang]] {
+		// ex. s.Id="10", s.TitleSlug="binary-tree", s.Lang="python3" then fileName = "10binary-tree-python3.py"
+		fileName = h.buildLangFileName(s.Id, s.TitleSlug, s.Lang)
+	}
+	// ex. s.Id="10", s.Title="Binary Tree", then folderName = "10 Binary Tree"
+	folderName = fmt.Sprintf("%v %v", s.Id, s.Title)
+	return folderName, fileName
 }
 
 // Takes the id, titleSlug and lang to return the fileName
 //
 // It will follow the format <id><titleSlug>.<langExtension>
 //
-// It figures out the lang extension using its internal langFileExtension map
+// It figures out the lang extension using the langFileExtension map
 // if there any code client support a new language then add it here to avoid future errors
 // currently this map was only formed using LeetCode's lang name
 func (Handler) buildFileName(id, titleSlug, lang string) string {
-	langFileExtension := map[string]string{
-		"cpp":        "cpp",
-		"java":       "java",
-		"python":     "py",
-		"python3":    "py",
-		"mysql":      "sql",
-		"mssql":      "sql",
-		"oraclesql":  "sql",
-		"c":          "c",
-		"csharp":     "cs",
-		"javascript": "js",
-		"typescript": "ts",
-		"bash":       "sh",
-		"php":        "php",
-		"swift":      "swift",
-		"kotlin":     "kt",
-		"dart":       "dart",
-		"golang":     "go",
-		"ruby":       "rb",
-		"scala":      "scala",
-		"rust":       "rs",
-		"racket":     "rkt",
-		"erlang":     "erl",
-		"elixir":     "ex",
-		"postgresql": "sql",
-	}
 	return fmt.Sprintf("%s%s.%s", id, titleSlug, langFileExtension[lang])
 }
+
+// Same as buildFileName but follows the format <id><titleSlug>-<lang>.<langExtension>
+//
+// Used with cfg.AllLanguages for the languages sharing their extension with another one (ex. python and python3),
+// so each language gets its own file side by side in the question's folder
+func (Handler) buildLangFileName(id, titleSlug, lang string) string {
+	return fmt.Sprintf("%s%s-%s.%s", id, titleSlug, lang, langFileExtension[lang])
+}
+
+// The extensions of langFileExtension that more than one language maps to
+var sharedExtensions = findSharedExtensions()
+
+// Returns the set of the extensions of langFileExtension that more than one language maps to
+func findSharedExtensions() map[string]bool {
+	langsByExtension := map[string]int{}
+	for _, extension := range langFileExtension {
+		langsByExtension[extension]++
+	}
+	shared := map[string]bool{}
+	for extension, langs := range langsByExtension {
+		shared[extension] = langs > 1
+	}
+	return shared
+}
+
+var langFileExtension = map[string]string{
+	"cpp":        "cpp",
+	"java":       "java",
+	"python":     "py",
+	"python3":    "py",
+	"mysql":      "sql",
+	"mssql":      "sql",
+	"oraclesql":  "sql",
+	"c":          "c",
+	"csharp":     "cs",
+	"javascript": "js",
+	"typescript": "ts",
+	"bash":       "sh",
+	"php":        "php",
+	"swift":      "swift",
+	"kotlin":     "kt",
+	"dart":       "dart",
+	"golang":     "go",
+	"ruby":       "rb",
+	"scala":      "scala",
+	"rust":       "rs",
+	"racket":     "rkt",
+	"erlang":     "erl",
+	"elixir":     "ex",
+	"postgresql": "sql",
+}
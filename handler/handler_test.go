@@ -1,13 +1,21 @@
 package handler
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/ahmed-e-abdulaziz/glsync/code"
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/ahmed-e-abdulaziz/glsync/git"
 	"github.com/ahmed-e-abdulaziz/glsync/mocks/mock_code"
 	"github.com/ahmed-e-abdulaziz/glsync/mocks/mock_git"
+	"github.com/ahmed-e-abdulaziz/glsync/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
@@ -17,19 +25,162 @@ func TestExecute(t *testing.T) {
 
 	subs := stubSubmissions()
 	gomock.InOrder(
-		mockCodeClient.EXPECT().FetchSubmissions().Return(subs, nil).Times(1),
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
 		mockGitClient.EXPECT().
-			Commit("1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum", subs[0].LastSubmittedAt).
+			Commit(gomock.Any(), "1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum", subs[0].LastSubmittedAt).
 			Return(nil).
 			Times(1),
 		mockGitClient.EXPECT().
-			Commit("2 Add Two Numbers", "2add-two-numbers.go", subs[1].Code, "Code challenge submission for question: 2 Add Two Numbers", subs[1].LastSubmittedAt).
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers.go", subs[1].Code, "Code challenge submission for question: 2 Add Two Numbers", subs[1].LastSubmittedAt).
 			Return(nil).
 			Times(1),
-		mockGitClient.EXPECT().Push().Return(nil).Times(1),
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(nil).Times(1),
 	)
 
-	NewHandler(mockCodeClient, mockGitClient).Execute()
+	err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestExecuteShouldWriteEachLanguageSideBySide(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	subs[1].Id, subs[1].Title, subs[1].TitleSlug, subs[1].Lang = "1", "Two Sum", "two-sum", "java"
+	subs = append(subs,
+		code.Submission{Id: "2", Title: "Add Two Numbers", TitleSlug: "add-two-numbers", Lang: "python", Code: "pass\n"},
+		code.Submission{Id: "2", Title: "Add Two Numbers", TitleSlug: "add-two-numbers", Lang: "python3", Code: "pass\n"},
+	)
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum", subs[0].LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "1 Two Sum", "1two-sum.java", subs[1].Code, "Code challenge submission for question: 1 Two Sum", subs[1].LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers-python.py", subs[2].Code, "Code challenge submission for question: 2 Add Two Numbers", subs[2].LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers-python3.py", subs[3].Code, "Code challenge submission for question: 2 Add Two Numbers", subs[3].LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(nil).Times(1),
+	)
+
+	err := NewHandler(config.Config{AllLanguages: true}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestExecuteShouldKeepTheFileOfALanguageWhenALaterSyncFetchesItAlone(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	python := code.Submission{Id: "2", Title: "Add Two Numbers", TitleSlug: "add-two-numbers", Lang: "python", Code: "pass\n"}
+	python3 := code.Submission{Id: "2", Title: "Add Two Numbers", TitleSlug: "add-two-numbers", Lang: "python3", Code: "pass\n"}
+	updatedPython3 := python3
+	updatedPython3.Code = "return None\n"
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return([]code.Submission{python, python3}, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers-python.py", python.Code, gomock.Any(), python.LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers-python3.py", python3.Code, gomock.Any(), python3.LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(nil).Times(1),
+		// The next sync only fetches the python3 submission, which goes to the same file
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return([]code.Submission{updatedPython3}, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers-python3.py", updatedPython3.Code, gomock.Any(), updatedPython3.LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(nil).Times(1),
+	)
+	handler := NewHandler(config.Config{AllLanguages: true}, mockCodeClient, mockGitClient)
+
+	firstErr := handler.Execute(context.Background())
+	secondErr := handler.Execute(context.Background())
+
+	assert.NoError(t, firstErr)
+	assert.NoError(t, secondErr)
+}
+
+func TestExecuteShouldSkipSubmissionsSyncedBeforeAndSaveState(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	lastSyncedAt := parseRFC3339("2024-12-01T00:00:00+02:00")
+	require.NoError(t, state.State{LastSyncedAt: lastSyncedAt, SubmissionIds: []string{"100"}}.Save(stateFile))
+	subs := stubSubmissions()
+	subs[0].SubmissionId, subs[1].SubmissionId = "100", "200"
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), lastSyncedAt).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers.go", subs[1].Code, "Code challenge submission for question: 2 Add Two Numbers\n\nGlsync-Submission-Id: 200\nGlsync-Site: com", subs[1].LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(nil).Times(1),
+	)
+
+	err := NewHandler(config.Config{StateFile: stateFile}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	require.NoError(t, err)
+
+	st, err := state.Load(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100", "200"}, st.SubmissionIds)
+	assert.True(t, st.LastSyncedAt.After(lastSyncedAt))
+}
+
+func TestExecuteShouldSkipSubmissionsCommittedBeforeAndAddTrailers(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	subs[0].SubmissionId, subs[1].SubmissionId = "100", "200"
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "cn").Return(map[string]bool{"100": true}, nil).Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers.go", subs[1].Code, "Code challenge submission for question: 2 Add Two Numbers\n\nGlsync-Submission-Id: 200\nGlsync-Site: cn", subs[1].LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(nil).Times(1),
+	)
+
+	err := NewHandler(config.Config{LcSite: "cn"}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestExecuteShouldReturnErrorWhenReadingTheHistoryFails(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(stubSubmissions(), nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(nil, errors.New("git log failed")).Times(1),
+	)
+	mockGitClient.EXPECT().Commit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	assert.ErrorIs(t, err, ErrGitFailed)
 }
 
 func stubSubmissions() []code.Submission {
@@ -54,61 +205,151 @@ func stubSubmissions() []code.Submission {
 	return subs
 }
 
-func TestExecuteShouldPanicWhenFetchSubmissionFails(t *testing.T) {
+func TestExecuteShouldReturnErrorWhenFetchSubmissionFails(t *testing.T) {
 	ctrl, mockCodeClient, mockGitClient := initMocks(t)
 	defer ctrl.Finish()
-	mockCodeClient.EXPECT().FetchSubmissions().Return(nil, errors.New("mock error")).Times(1)
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("The code did not panic although fetch sumbissions failed")
-		}
-	}()
-	NewHandler(mockCodeClient, mockGitClient).Execute()
+	mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(nil, code.ErrSessionExpired).Times(1)
+
+	err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	assert.ErrorIs(t, err, ErrFetchFailed)
+	assert.ErrorIs(t, err, code.ErrSessionExpired)
 }
 
-func TestExecuteShouldContinueWhenACommitFails(t *testing.T) {
+func TestExecuteShouldContinueWhenACommitFailsAndReturnPartialSyncError(t *testing.T) {
 	ctrl, mockCodeClient, mockGitClient := initMocks(t)
 	defer ctrl.Finish()
 
+	stateFile := filepath.Join(t.TempDir(), "state.json")
 	subs := stubSubmissions()
+	subs[0].SubmissionId, subs[1].SubmissionId = "100", "200"
 	gomock.InOrder(
-		mockCodeClient.EXPECT().FetchSubmissions().Return(subs, nil).Times(1),
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
 		mockGitClient.EXPECT().
-			Commit("1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum", subs[0].LastSubmittedAt).
+			Commit(gomock.Any(), "1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum\n\nGlsync-Submission-Id: 100\nGlsync-Site: com", subs[0].LastSubmittedAt).
 			Return(nil).
 			Times(1),
 		mockGitClient.EXPECT().
-			Commit("2 Add Two Numbers", "2add-two-numbers.go", subs[1].Code, "Code challenge submission for question: 2 Add Two Numbers", subs[1].LastSubmittedAt).
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers.go", subs[1].Code, "Code challenge submission for question: 2 Add Two Numbers\n\nGlsync-Submission-Id: 200\nGlsync-Site: com", subs[1].LastSubmittedAt).
 			Return(errors.New("Second Commit Failed")). // Commit Failure
 			Times(1),
-		mockGitClient.EXPECT().Push().Return(nil).Times(1), // Push should happen regardless of failure
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(nil).Times(1), // Push should happen regardless of failure
+	)
+
+	err := NewHandler(config.Config{StateFile: stateFile}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	assert.ErrorIs(t, err, ErrPartialSync)
+	st, err := state.Load(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100"}, st.SubmissionIds)
+	assert.True(t, st.LastSyncedAt.IsZero(), "The last sync time should stay as it was so the failed submission is fetched again")
+}
+
+func TestExecuteShouldRecordTheSubmissionsThatHaveNothingToCommit(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	subs := stubSubmissions()
+	subs[0].SubmissionId, subs[1].SubmissionId = "100", "200"
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum\n\nGlsync-Submission-Id: 100\nGlsync-Site: com", subs[0].LastSubmittedAt).
+			Return(fmt.Errorf("%w, working tree clean", git.ErrNothingToCommit)). // The repo already has the code
+			Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers.go", subs[1].Code, "Code challenge submission for question: 2 Add Two Numbers\n\nGlsync-Submission-Id: 200\nGlsync-Site: com", subs[1].LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(nil).Times(1),
+	)
+
+	err := NewHandler(config.Config{StateFile: stateFile}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	require.NoError(t, err)
+	st, err := state.Load(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100", "200"}, st.SubmissionIds)
+}
+
+func TestExecuteShouldCommitTheOtherQuestionsAndReturnPartialSyncErrorWhenSomeFailToBeFetched(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	lastSyncedAt := parseRFC3339("2024-12-01T00:00:00+02:00")
+	require.NoError(t, state.State{LastSyncedAt: lastSyncedAt}.Save(stateFile))
+	subs := stubSubmissions()[:1]
+	subs[0].SubmissionId = "100"
+	fetchErr := fmt.Errorf("%w, 1 of 2 questions failed: %w", code.ErrPartialFetch, code.ErrRateLimited)
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), lastSyncedAt).Return(subs, fetchErr).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum\n\nGlsync-Submission-Id: 100\nGlsync-Site: com", subs[0].LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(nil).Times(1),
 	)
-	NewHandler(mockCodeClient, mockGitClient).Execute()
+
+	err := NewHandler(config.Config{StateFile: stateFile}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	assert.ErrorIs(t, err, ErrPartialSync)
+	assert.NotErrorIs(t, err, code.ErrRateLimited, "The exit code should be the partial sync one")
+	st, err := state.Load(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100"}, st.SubmissionIds)
+	assert.True(t, st.LastSyncedAt.Equal(lastSyncedAt), "The last sync time should stay as it was so the failed questions are fetched again")
 }
 
-func TestExecuteShouldPanicWhenPushFails(t *testing.T) {
+func TestExecuteShouldStopWithoutPushingWhenContextIsCancelled(t *testing.T) {
 	ctrl, mockCodeClient, mockGitClient := initMocks(t)
 	defer ctrl.Finish()
 
+	ctx, cancel := context.WithCancel(context.Background())
 	subs := stubSubmissions()
 	gomock.InOrder(
-		mockCodeClient.EXPECT().FetchSubmissions().Return(subs, nil).Times(1),
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
 		mockGitClient.EXPECT().
-			Commit("1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum", subs[0].LastSubmittedAt).
+			Commit(gomock.Any(), "1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum", subs[0].LastSubmittedAt).
+			Do(func(context.Context, string, string, string, string, time.Time) { cancel() }). // Interrupted while committing
 			Return(nil).
 			Times(1),
+	)
+	mockGitClient.EXPECT().Push(gomock.Any()).Times(0)
+
+	err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Execute(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestExecuteShouldReturnErrorWhenPushFails(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{}, nil).Times(1),
 		mockGitClient.EXPECT().
-			Commit("2 Add Two Numbers", "2add-two-numbers.go", subs[1].Code, "Code challenge submission for question: 2 Add Two Numbers", subs[1].LastSubmittedAt).
+			Commit(gomock.Any(), "1 Two Sum", "1two-sum.go", subs[0].Code, "Code challenge submission for question: 1 Two Sum", subs[0].LastSubmittedAt).
 			Return(nil).
 			Times(1),
-		mockGitClient.EXPECT().Push().Return(errors.New("Error happened while pushing")).Times(1), // git.Push() fails
+		mockGitClient.EXPECT().
+			Commit(gomock.Any(), "2 Add Two Numbers", "2add-two-numbers.go", subs[1].Code, "Code challenge submission for question: 2 Add Two Numbers", subs[1].LastSubmittedAt).
+			Return(nil).
+			Times(1),
+		mockGitClient.EXPECT().Push(gomock.Any()).Return(errors.New("Error happened while pushing")).Times(1), // git.Push() fails
 	)
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("The code did not panic although git push failed")
-		}
-	}()
-	NewHandler(mockCodeClient, mockGitClient).Execute()
+
+	err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Execute(context.Background())
+
+	assert.ErrorIs(t, err, ErrGitFailed)
+	assert.ErrorContains(t, err, "Error happened while pushing")
 }
 
 func initMocks(t *testing.T) (*gomock.Controller, *mock_code.MockCodeClient, *mock_git.MockGitClient) {
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/code"
+)
+
+// Stats summarizes the submissions a full sync commits
+type Stats struct {
+	Questions        int
+	Submissions      int
+	Languages        map[string]int // Number of submissions in each language, keyed by the site's language name
+	FirstSubmittedAt time.Time
+	LastSubmittedAt  time.Time
+}
+
+// Stats fetches every submission a full sync would commit and summarizes them, the repo isn't cloned.
+// If only some questions fail to be fetched, the stats of the others are returned with an ErrPartialSync error.
+func (h Handler) Stats(ctx context.Context) (Stats, error) {
+	submissions, err := h.codeClient.FetchSubmissions(ctx, time.Time{})
+	var partialErr error
+	if errors.Is(err, code.ErrPartialFetch) {
+		log.Printf("Warning: %v, summarizing the submissions of the other questions\n", err)
+		// Not wrapped, the exit code has to be the partial sync one whatever the questions failed with
+		partialErr = fmt.Errorf("%w, %v", ErrPartialSync, err)
+	} else if err != nil {
+		return Stats{}, fmt.Errorf("%w: %w", ErrFetchFailed, err)
+	}
+	stats := Stats{Submissions: len(submissions), Languages: map[string]int{}}
+	questions := map[string]bool{}
+	for _, s := range submissions {
+		questions[s.Id] = true
+		stats.Languages[s.Lang]++
+		if stats.FirstSubmittedAt.IsZero() || s.LastSubmittedAt.Before(stats.FirstSubmittedAt) {
+			stats.FirstSubmittedAt = s.LastSubmittedAt
+		}
+		if s.LastSubmittedAt.After(stats.LastSubmittedAt) {
+			stats.LastSubmittedAt = s.LastSubmittedAt
+		}
+	}
+	stats.Questions = len(questions)
+	return stats, partialErr
+}
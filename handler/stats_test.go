@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/code"
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStatsShouldSummarizeEverySubmission(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	subs = append(subs, code.Submission{Id: "1", Title: "Two Sum", TitleSlug: "two-sum", Lang: "python3",
+		LastSubmittedAt: parseRFC3339("2025-01-02T00:00:00+02:00"), Code: "pass\n"})
+	mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1)
+
+	stats, err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Stats(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Stats{
+		Questions:        2,
+		Submissions:      3,
+		Languages:        map[string]int{"golang": 2, "python3": 1},
+		FirstSubmittedAt: subs[1].LastSubmittedAt,
+		LastSubmittedAt:  subs[2].LastSubmittedAt,
+	}, stats)
+}
+
+func TestStatsShouldSummarizeTheOtherQuestionsAndReturnPartialSyncErrorWhenSomeFailToBeFetched(t *testing.T) {
+	ctrl, mockCodeClient, _ := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	partialErr := fmt.Errorf("%w, 1 of 3 questions failed: %w", code.ErrPartialFetch, code.ErrRateLimited)
+	mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, partialErr).Times(1)
+
+	stats, err := NewHandler(config.Config{}, mockCodeClient, nil).Stats(context.Background())
+
+	assert.ErrorIs(t, err, ErrPartialSync)
+	assert.NotErrorIs(t, err, code.ErrRateLimited)
+	assert.Equal(t, 2, stats.Questions)
+	assert.Equal(t, 2, stats.Submissions)
+}
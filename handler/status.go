@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ahmed-e-abdulaziz/glsync/code"
+)
+
+// Status returns the submissions the next Execute would commit, in the order it would commit them,
+// without cloning the repo or touching the state file.
+//
+// Like Execute, it only fetches questions submitted since the last sync and skips the submissions
+// committed before if cfg.StateFile is set. The git client is optional here, as there is no repo to read
+// without cloning it, but if the handler has one the submissions in the trailers of its commits are skipped too.
+// Otherwise every submission is returned.
+//
+// If only some questions fail to be fetched, the pending submissions of the others are returned with
+// an ErrPartialSync error like Execute returns.
+func (h Handler) Status(ctx context.Context) ([]code.Submission, error) {
+	st := h.loadState()
+	submissions, err := h.codeClient.FetchSubmissions(ctx, st.LastSyncedAt)
+	var partialErr error
+	if errors.Is(err, code.ErrPartialFetch) {
+		log.Printf("Warning: %v, listing the submissions of the other questions\n", err)
+		// Not wrapped, the exit code has to be the partial sync one whatever the questions failed with
+		partialErr = fmt.Errorf("%w, %v", ErrPartialSync, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFetchFailed, err)
+	}
+	syncedSubmissions := st.SyncedSubmissions()
+	committedSubmissions := map[string]bool{}
+	if h.git != nil {
+		committedSubmissions, err = h.git.SyncedSubmissionIds(ctx, h.site())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrGitFailed, err)
+		}
+	}
+	pending := make([]code.Submission, 0, len(submissions))
+	for _, s := range submissions {
+		if s.SubmissionId == "" || !(syncedSubmissions[s.SubmissionId] || committedSubmissions[s.SubmissionId]) {
+			pending = append(pending, s)
+		}
+	}
+	return pending, partialErr
+}
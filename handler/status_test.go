@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/code"
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/ahmed-e-abdulaziz/glsync/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStatusShouldReturnSubmissionsNotSyncedBefore(t *testing.T) {
+	ctrl, mockCodeClient, _ := initMocks(t)
+	defer ctrl.Finish()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	lastSyncedAt := parseRFC3339("2024-12-01T00:00:00+02:00")
+	require.NoError(t, state.State{LastSyncedAt: lastSyncedAt, SubmissionIds: []string{"100"}}.Save(stateFile))
+	subs := stubSubmissions()
+	subs[0].SubmissionId, subs[1].SubmissionId = "100", "200"
+	mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), lastSyncedAt).Return(subs, nil).Times(1)
+
+	pending, err := NewHandler(config.Config{StateFile: stateFile}, mockCodeClient, nil).Status(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []code.Submission{subs[1]}, pending)
+	st, err := state.Load(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, lastSyncedAt.Unix(), st.LastSyncedAt.Unix())
+}
+
+func TestStatusShouldReturnEverySubmissionWithoutAStateFile(t *testing.T) {
+	ctrl, mockCodeClient, _ := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1)
+
+	pending, err := NewHandler(config.Config{}, mockCodeClient, nil).Status(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, subs, pending)
+}
+
+func TestStatusShouldReturnErrorWhenFetchSubmissionFails(t *testing.T) {
+	ctrl, mockCodeClient, _ := initMocks(t)
+	defer ctrl.Finish()
+
+	mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(nil, code.ErrSessionExpired).Times(1)
+
+	_, err := NewHandler(config.Config{}, mockCodeClient, nil).Status(context.Background())
+
+	assert.True(t, errors.Is(err, code.ErrSessionExpired))
+}
+
+func TestStatusShouldSkipTheSubmissionsCommittedToTheRepo(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, state.State{SubmissionIds: []string{"100"}}.Save(stateFile))
+	subs := stubSubmissions()
+	subs = append(subs, subs[1])
+	subs[0].SubmissionId, subs[1].SubmissionId, subs[2].SubmissionId = "100", "200", "300"
+	mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1)
+	mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(map[string]bool{"200": true}, nil).Times(1)
+
+	pending, err := NewHandler(config.Config{StateFile: stateFile}, mockCodeClient, mockGitClient).Status(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []code.Submission{subs[2]}, pending)
+}
+
+func TestStatusShouldReturnGitFailedErrorWhenTheTrailersCantBeRead(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(stubSubmissions(), nil).Times(1)
+	mockGitClient.EXPECT().SyncedSubmissionIds(gomock.Any(), "com").Return(nil, errors.New("git log failed")).Times(1)
+
+	_, err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Status(context.Background())
+
+	assert.ErrorIs(t, err, ErrGitFailed)
+}
+
+func TestStatusShouldReturnTheOtherQuestionsAndPartialSyncErrorWhenSomeFailToBeFetched(t *testing.T) {
+	ctrl, mockCodeClient, _ := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	partialErr := fmt.Errorf("%w, 1 of 3 questions failed: %w", code.ErrPartialFetch, code.ErrRateLimited)
+	mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, partialErr).Times(1)
+
+	pending, err := NewHandler(config.Config{}, mockCodeClient, nil).Status(context.Background())
+
+	assert.ErrorIs(t, err, ErrPartialSync)
+	assert.NotErrorIs(t, err, code.ErrRateLimited)
+	assert.Equal(t, subs, pending)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/code"
+)
+
+// Mismatch is a file of the repo that doesn't have the code of the submission it should have
+type Mismatch struct {
+	Submission code.Submission
+	Path       string // The file's path in the repo, ex. "10 Binary Tree/10binary-tree.go"
+	Missing    bool   // The file doesn't exist, otherwise it has different code
+}
+
+// Verify fetches every submission and checks that the repo has each one's code in the file Execute
+// commits it to, returning the files that don't. When several submissions are committed to the same
+// file, as with cfg.AllSubmissions, the file is expected to have the last one's code.
+//
+// The local copy of the repo is removed once it's done, nothing is committed or pushed.
+func (h Handler) Verify(ctx context.Context) ([]Mismatch, error) {
+	submissions, err := h.codeClient.FetchSubmissions(ctx, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFetchFailed, err)
+	}
+	log.Printf("Fetched %v submissions, will verify them next\n", len(submissions))
+	var paths []string
+	expected := map[string]code.Submission{}
+	for _, s := range submissions {
+		folderName, fileName := h.buildPath(s)
+		path := folderName + "/" + fileName
+		if _, ok := expected[path]; !ok {
+			paths = append(paths, path)
+		}
+		expected[path] = s
+	}
+
+	var mismatches []Mismatch
+	for _, path := range paths {
+		s := expected[path]
+		folderName, fileName := h.buildPath(s)
+		content, err := h.git.ReadFile(folderName, fileName)
+		if errors.Is(err, fs.ErrNotExist) {
+			mismatches = append(mismatches, Mismatch{s, path, true})
+			continue
+		}
+		if err != nil {
+			h.git.Close()
+			return nil, fmt.Errorf("%w, couldn't read %v from the repo: %w", ErrGitFailed, path, err)
+		}
+		if content != s.Code {
+			mismatches = append(mismatches, Mismatch{s, path, false})
+		}
+	}
+	if err := h.git.Close(); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
+	return mismatches, nil
+}
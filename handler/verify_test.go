@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/glsync/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVerifyShouldReturnMissingAndDifferentFiles(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	subs = append(subs, subs[0], subs[1])
+	subs[0].Code, subs[1].Code = "old code\n", "old code\n"
+	subs[2].Code, subs[3].Code = "new code\n", "different code\n"
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().ReadFile("1 Two Sum", "1two-sum.go").Return("", fs.ErrNotExist).Times(1),
+		mockGitClient.EXPECT().ReadFile("2 Add Two Numbers", "2add-two-numbers.go").Return("new code\n", nil).Times(1),
+		mockGitClient.EXPECT().Close().Return(nil).Times(1),
+	)
+
+	mismatches, err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Verify(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []Mismatch{
+		{subs[2], "1 Two Sum/1two-sum.go", true},
+		{subs[3], "2 Add Two Numbers/2add-two-numbers.go", false},
+	}, mismatches)
+}
+
+func TestVerifyShouldReturnNothingWhenTheRepoIsInSync(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().ReadFile("1 Two Sum", "1two-sum.go").Return(subs[0].Code, nil).Times(1),
+		mockGitClient.EXPECT().ReadFile("2 Add Two Numbers", "2add-two-numbers.go").Return(subs[1].Code, nil).Times(1),
+		mockGitClient.EXPECT().Close().Return(nil).Times(1),
+	)
+
+	mismatches, err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Verify(context.Background())
+
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
+
+func TestVerifyShouldReturnErrorWhenReadingTheRepoFails(t *testing.T) {
+	ctrl, mockCodeClient, mockGitClient := initMocks(t)
+	defer ctrl.Finish()
+
+	subs := stubSubmissions()
+	gomock.InOrder(
+		mockCodeClient.EXPECT().FetchSubmissions(gomock.Any(), time.Time{}).Return(subs, nil).Times(1),
+		mockGitClient.EXPECT().ReadFile("1 Two Sum", "1two-sum.go").Return("", errors.New("permission denied")).Times(1),
+		mockGitClient.EXPECT().Close().Return(nil).Times(1),
+	)
+
+	_, err := NewHandler(config.Config{}, mockCodeClient, mockGitClient).Verify(context.Background())
+
+	assert.ErrorContains(t, err, "permission denied")
+}
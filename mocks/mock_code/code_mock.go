@@ -10,7 +10,9 @@
 package mock_code
 
 import (
+        context "context"
         reflect "reflect"
+        time "time"
 
         code "github.com/ahmed-e-abdulaziz/glsync/code"
         gomock "go.uber.org/mock/gomock"
@@ -41,16 +43,16 @@ func (m *MockCodeClient) EXPECT() *MockCodeClientMockRecorder {
 }
 
 // FetchSubmissions mocks base method.
-func (m *MockCodeClient) FetchSubmissions() ([]code.Submission, error) {
+func (m *MockCodeClient) FetchSubmissions(ctx context.Context, since time.Time) ([]code.Submission, error) {
         m.ctrl.T.Helper()
-        ret := m.ctrl.Call(m, "FetchSubmissions")
+        ret := m.ctrl.Call(m, "FetchSubmissions", ctx, since)
         ret0, _ := ret[0].([]code.Submission)
         ret1, _ := ret[1].(error)
         return ret0, ret1
 }
 
 // FetchSubmissions indicates an expected call of FetchSubmissions.
-func (mr *MockCodeClientMockRecorder) FetchSubmissions() *gomock.Call {
+func (mr *MockCodeClientMockRecorder) FetchSubmissions(ctx, since any) *gomock.Call {
         mr.mock.ctrl.T.Helper()
-        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchSubmissions", reflect.TypeOf((*MockCodeClient)(nil).FetchSubmissions))
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchSubmissions", reflect.TypeOf((*MockCodeClient)(nil).FetchSubmissions), ctx, since)
 }
\ No newline at end of file
@@ -10,6 +10,7 @@
 package mock_git
 
 import (
+        context "context"
         reflect "reflect"
         time "time"
 
@@ -40,30 +41,74 @@ func (m *MockGitClient) EXPECT() *MockGitClientMockRecorder {
         return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockGitClient) Close() error {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "Close")
+        ret0, _ := ret[0].(error)
+        return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockGitClientMockRecorder) Close() *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockGitClient)(nil).Close))
+}
+
 // Commit mocks base method.
-func (m *MockGitClient) Commit(folderName, fileName, code, commitMessage string, timestamp time.Time) error {
+func (m *MockGitClient) Commit(ctx context.Context, folderName, fileName, code, commitMessage string, timestamp time.Time) error {
         m.ctrl.T.Helper()
-        ret := m.ctrl.Call(m, "Commit", folderName, fileName, code, commitMessage, timestamp)
+        ret := m.ctrl.Call(m, "Commit", ctx, folderName, fileName, code, commitMessage, timestamp)
         ret0, _ := ret[0].(error)
         return ret0
 }
 
 // Commit indicates an expected call of Commit.
-func (mr *MockGitClientMockRecorder) Commit(folderName, fileName, code, commitMessage, timestamp any) *gomock.Call {
+func (mr *MockGitClientMockRecorder) Commit(ctx, folderName, fileName, code, commitMessage, timestamp any) *gomock.Call {
         mr.mock.ctrl.T.Helper()
-        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockGitClient)(nil).Commit), folderName, fileName, code, commitMessage, timestamp)
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockGitClient)(nil).Commit), ctx, folderName, fileName, code, commitMessage, timestamp)
 }
 
 // Push mocks base method.
-func (m *MockGitClient) Push() error {
+func (m *MockGitClient) Push(ctx context.Context) error {
         m.ctrl.T.Helper()
-        ret := m.ctrl.Call(m, "Push")
+        ret := m.ctrl.Call(m, "Push", ctx)
         ret0, _ := ret[0].(error)
         return ret0
 }
 
 // Push indicates an expected call of Push.
-func (mr *MockGitClientMockRecorder) Push() *gomock.Call {
+func (mr *MockGitClientMockRecorder) Push(ctx any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockGitClient)(nil).Push), ctx)
+}
+
+// ReadFile mocks base method.
+func (m *MockGitClient) ReadFile(folderName, fileName string) (string, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "ReadFile", folderName, fileName)
+        ret0, _ := ret[0].(string)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// ReadFile indicates an expected call of ReadFile.
+func (mr *MockGitClientMockRecorder) ReadFile(folderName, fileName any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadFile", reflect.TypeOf((*MockGitClient)(nil).ReadFile), folderName, fileName)
+}
+
+// SyncedSubmissionIds mocks base method.
+func (m *MockGitClient) SyncedSubmissionIds(ctx context.Context, site string) (map[string]bool, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "SyncedSubmissionIds", ctx, site)
+        ret0, _ := ret[0].(map[string]bool)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// SyncedSubmissionIds indicates an expected call of SyncedSubmissionIds.
+func (mr *MockGitClientMockRecorder) SyncedSubmissionIds(ctx, site any) *gomock.Call {
         mr.mock.ctrl.T.Helper()
-        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockGitClient)(nil).Push))
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncedSubmissionIds", reflect.TypeOf((*MockGitClient)(nil).SyncedSubmissionIds), ctx, site)
 }
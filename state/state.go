@@ -0,0 +1,65 @@
+// This package is responsible for remembering what was already synced between glsync runs
+// so later runs only fetch and commit what changed since then
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type State struct {
+	LastSyncedAt  time.Time `json:"lastSyncedAt"`  // When the last successful sync started, questions submitted before it are skipped
+	SubmissionIds []string  `json:"submissionIds"` // Ids of the submissions that were committed and pushed already
+}
+
+// Loads the state from the JSON file at path
+//
+// Returns an empty State if the file doesn't exist yet, as it is the case in the first run
+func Load(path string) (State, error) {
+	st := State{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("couldn't read sync state file %s: %w", path, err)
+	}
+	if err = json.Unmarshal(content, &st); err != nil {
+		return st, fmt.Errorf("couldn't parse sync state file %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// Saves the state as JSON to the file at path, creating its parent folder if needed
+//
+// The file is written to a temp file first then renamed so an interrupted run can't corrupt it
+func (s State) Save(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't encode sync state: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("couldn't create the folder of sync state file %s: %w", path, err)
+	}
+	tmpPath := path + ".tmp"
+	if err = os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return fmt.Errorf("couldn't write sync state file %s: %w", tmpPath, err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("couldn't replace sync state file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Returns the set of submission ids that were synced already
+func (s State) SyncedSubmissions() map[string]bool {
+	synced := make(map[string]bool, len(s.SubmissionIds))
+	for _, id := range s.SubmissionIds {
+		synced[id] = true
+	}
+	return synced
+}
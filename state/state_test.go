@@ -0,0 +1,50 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadShouldReturnEmptyStateWhenFileDoesNotExist(t *testing.T) {
+	// When
+	st, err := Load(filepath.Join(t.TempDir(), "state.json"))
+
+	// Then
+	assert.NoError(t, err)
+	assert.True(t, st.LastSyncedAt.IsZero())
+	assert.Empty(t, st.SubmissionIds)
+}
+
+func TestSaveThenLoad(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	lastSyncedAt := time.Date(2024, 12, 28, 17, 25, 31, 0, time.UTC)
+	st := State{LastSyncedAt: lastSyncedAt, SubmissionIds: []string{"1", "2"}}
+
+	// When
+	err := st.Save(path)
+	loaded, loadErr := Load(path)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, loadErr)
+	assert.True(t, lastSyncedAt.Equal(loaded.LastSyncedAt))
+	assert.Equal(t, map[string]bool{"1": true, "2": true}, loaded.SyncedSubmissions())
+}
+
+func TestLoadShouldFailWhenFileIsCorrupted(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	// When
+	_, err := Load(path)
+
+	// Then
+	assert.Error(t, err)
+}